@@ -0,0 +1,387 @@
+package habit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Achievement represents an achievement unlocked on a day.
+type Achievement struct {
+	ID   string    `json:"id"`
+	Date time.Time `json:"date"`
+}
+
+// Rule describes an achievement and the condition unlocking it.
+type Rule struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	When        Condition `json:"when"`
+}
+
+// Condition holds requirements of a rule. All requirements
+// that are set must hold for the rule to unlock.
+//
+// Rules are unlocked by each habit on its own, unless they're
+// global or require perfect days. Global rules are unlocked once,
+// by completions of all habits counted together, and their
+// streak requirements hold if any habit meets them.
+type Condition struct {
+	Global       bool   `json:"global,omitempty"`
+	Streak       int    `json:"streak,omitempty"`        // Streak holds the minimal length of the current streak.
+	Completions  int    `json:"completions,omitempty"`   // Completions holds the minimal number of completions.
+	LoggedBefore string `json:"logged_before,omitempty"` // LoggedBefore, like "07:00", counts only completions logged before the time of day.
+	Comeback     int    `json:"comeback,omitempty"`      // Comeback holds the minimal length of a broken streak followed by the current one.
+	PerfectDays  int    `json:"perfect_days,omitempty"`  // PerfectDays holds the number of last days every habit done on any of them was done on.
+}
+
+// DefaultRules holds built-in achievements.
+var DefaultRules = []Rule{
+	{
+		ID: "perfect-week", Name: "Perfect week",
+		Description: "do every habit 7 days in a row",
+		When:        Condition{PerfectDays: 7},
+	},
+	{
+		ID: "comeback", Name: "Comeback",
+		Description: "start a new streak after a broken streak of 30 days",
+		When:        Condition{Comeback: 30},
+	},
+	{
+		ID: "early-bird", Name: "Early bird",
+		Description: "log habits before 7am ten times",
+		When:        Condition{Global: true, Completions: 10, LoggedBefore: "07:00"},
+	},
+	{
+		ID: "century", Name: "Century",
+		Description: "do a habit 100 times",
+		When:        Condition{Completions: 100},
+	},
+}
+
+// rules holds achievement rules in use.
+var rules = DefaultRules
+
+// global reports whether the rule is unlocked once for all habits.
+func (r Rule) global() bool {
+	return r.When.Global || r.When.PerfectDays > 0
+}
+
+// ruleByID returns the rule in use with the ID.
+func ruleByID(id string) (Rule, bool) {
+	for _, r := range rules {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+var ruleID = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// validate returns an error if the rule can't be evaluated.
+func (r Rule) validate() error {
+	if !ruleID.MatchString(r.ID) {
+		return fmt.Errorf("invalid id %q, want lowercase words joined with dashes", r.ID)
+	}
+	if r.Name == "" {
+		return fmt.Errorf("rule %s: name cannot be empty", r.ID)
+	}
+	c := r.When
+	if c.Streak < 0 || c.Completions < 0 || c.Comeback < 0 || c.PerfectDays < 0 {
+		return fmt.Errorf("rule %s: numbers of days cannot be negative", r.ID)
+	}
+	if c.Streak == 0 && c.Completions == 0 && c.Comeback == 0 && c.PerfectDays == 0 {
+		return fmt.Errorf("rule %s: no requirements, want one of: streak, completions, comeback, perfect_days", r.ID)
+	}
+	if c.LoggedBefore != "" {
+		if _, err := time.Parse("15:04", c.LoggedBefore); err != nil {
+			return fmt.Errorf("rule %s: invalid logged_before %q, want a time like 07:00", r.ID, c.LoggedBefore)
+		}
+		if c.Completions == 0 {
+			return fmt.Errorf("rule %s: logged_before requires completions", r.ID)
+		}
+	}
+	return nil
+}
+
+// LoadRules takes a path of a JSON file holding a list of rules
+// and adds them to DefaultRules. Rules with the ID of a built-in
+// rule replace it. It returns an error reporting every invalid
+// rule, and then keeps rules in use unchanged. A missing file
+// is not an error.
+func LoadRules(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		rules = DefaultRules
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var custom []Rule
+	if err := dec.Decode(&custom); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	var errs []error
+	seen := make(map[string]bool)
+	for _, r := range custom {
+		if err := r.validate(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if seen[r.ID] {
+			errs = append(errs, fmt.Errorf("rule %s: duplicate id", r.ID))
+		}
+		seen[r.ID] = true
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("loading achievements from %s: %w", path, errors.Join(errs...))
+	}
+	loaded := append([]Rule{}, custom...)
+	for _, r := range DefaultRules {
+		if !seen[r.ID] {
+			loaded = append(loaded, r)
+		}
+	}
+	sort.SliceStable(loaded, func(i, j int) bool { return loaded[i].ID < loaded[j].ID })
+	rules = loaded
+	return nil
+}
+
+// achievementsPath returns path to the file with the user's rules.
+//
+// It's habit/achievements.json in the user's config
+// directory, $XDG_CONFIG_HOME or $HOME/.config on Unix.
+func achievementsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "habit", "achievements.json")
+}
+
+// holds reports whether the condition holds for the habits on
+// the day. Global conditions are checked against all habits,
+// others against the first one.
+func (c Condition) holds(hx []Habit, day time.Time) bool {
+	if !c.Global && c.PerfectDays == 0 {
+		hx = hx[:1]
+	}
+	if c.Streak > 0 && !anyHabit(hx, func(h Habit) bool { return h.Stats().CurrentStreak >= c.Streak }) {
+		return false
+	}
+	if c.Comeback > 0 && !anyHabit(hx, func(h Habit) bool { return comeback(h, c.Comeback) }) {
+		return false
+	}
+	if c.Completions > 0 {
+		n := 0
+		for _, h := range hx {
+			n += c.countCompletions(h)
+		}
+		if n < c.Completions {
+			return false
+		}
+	}
+	if c.PerfectDays > 0 {
+		// Habits not done on any of the days were abandoned
+		// or not started yet, so they don't spoil them.
+		for _, h := range hx {
+			done := 0
+			for i := 0; i < c.PerfectDays; i++ {
+				if h.DoneOn(day.AddDate(0, 0, -i)) {
+					done++
+				}
+			}
+			if done > 0 && done < c.PerfectDays {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func anyHabit(hx []Habit, f func(Habit) bool) bool {
+	for _, h := range hx {
+		if f(h) {
+			return true
+		}
+	}
+	return false
+}
+
+// comeback reports whether the current streak of the habit
+// follows a broken streak at least n days long.
+func comeback(h Habit, n int) bool {
+	runs := streaks(h.Days(), h.Frequency.gap())
+	return len(runs) > 1 && runs[len(runs)-2] >= n
+}
+
+// countCompletions returns the number of completions of the
+// habit, counting only those logged before the time of day
+// of LoggedBefore, if it's set.
+func (c Condition) countCompletions(h Habit) int {
+	cx := h.Completions()
+	if c.LoggedBefore == "" {
+		return len(cx)
+	}
+	before, _ := time.Parse("15:04", c.LoggedBefore)
+	limit := before.Hour()*60 + before.Minute()
+	n := 0
+	for _, cp := range cx {
+		if cp.LoggedAt != nil && cp.LoggedAt.Hour()*60+cp.LoggedAt.Minute() < limit {
+			n++
+		}
+	}
+	return n
+}
+
+// hasAchievement reports whether the habit unlocked the achievement.
+func (h *Habit) hasAchievement(id string) bool {
+	for _, a := range h.Achievements {
+		if a.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// unlockAchievements evaluates rules in use after the habit was
+// recorded on the day of the given time. Achievements unlocked
+// are stored in the habit, global ones in the store, and it
+// returns messages announcing them.
+func (f *FileStore) unlockAchievements(habitName string, t time.Time) string {
+	h, ok := f.Get(habitName)
+	if !ok {
+		return ""
+	}
+	day := RoundDateToDay(t)
+	hx := []Habit{h}
+	for _, o := range f.GetAll() {
+		if o.Name != h.Name {
+			hx = append(hx, o)
+		}
+	}
+	global := make(map[string]bool)
+	for _, a := range f.GlobalAchievements() {
+		global[a.ID] = true
+	}
+	var sb strings.Builder
+	for _, r := range rules {
+		unlocked := h.hasAchievement(r.ID)
+		if r.global() {
+			unlocked = global[r.ID]
+		}
+		if unlocked || !r.When.holds(hx, day) {
+			continue
+		}
+		if r.global() {
+			f.AddGlobalAchievements([]Achievement{{ID: r.ID, Date: day}})
+		} else {
+			h.Achievements = append(h.Achievements, Achievement{ID: r.ID, Date: day})
+			hx[0] = h
+		}
+		data := h.messageData(0)
+		data.Achievement = r.Name
+		description := ""
+		if r.Description != "" {
+			description = " (" + r.Description + ")"
+		}
+		sb.WriteString(message(msgAchievement, 0, data, r.Name, description))
+	}
+	f.Add(h)
+	return sb.String()
+}
+
+// Unlocked represents an achievement unlocked by a habit, or
+// by all habits if Habit is empty.
+type Unlocked struct {
+	Rule  Rule
+	Habit string
+	Date  time.Time
+}
+
+// Achievements takes global achievements and habits and returns
+// achievements unlocked, oldest first, and rules in use not
+// unlocked yet. Achievements of rules no longer in use are skipped.
+//
+// Global achievements still stored in habits, by servers of
+// older versions, are listed once, with the earliest date.
+func Achievements(global []Achievement, hx []Habit) ([]Unlocked, []Rule) {
+	var unlocked []Unlocked
+	done := make(map[string]bool)
+	for _, h := range hx {
+		for _, a := range h.Achievements {
+			r, ok := ruleByID(a.ID)
+			if !ok {
+				continue
+			}
+			if r.global() {
+				global = mergeAchievements(global, []Achievement{a})
+				continue
+			}
+			done[r.ID] = true
+			unlocked = append(unlocked, Unlocked{Rule: r, Habit: h.Name, Date: a.Date})
+		}
+	}
+	for _, a := range global {
+		if r, ok := ruleByID(a.ID); ok {
+			done[r.ID] = true
+			unlocked = append(unlocked, Unlocked{Rule: r, Date: a.Date})
+		}
+	}
+	sort.SliceStable(unlocked, func(i, j int) bool { return unlocked[i].Date.Before(unlocked[j].Date) })
+	var locked []Rule
+	for _, r := range rules {
+		if !done[r.ID] {
+			locked = append(locked, r)
+		}
+	}
+	return unlocked, locked
+}
+
+func runAchievements(s Store, args []string, wr, ew io.Writer) int {
+	if len(args) != 0 {
+		fmt.Fprint(ew, errors.New("usage: habit achievements"))
+		return 1
+	}
+	var global []Achievement
+	if g, ok := s.(interface{ GlobalAchievements() []Achievement }); ok {
+		global = g.GlobalAchievements()
+	}
+	unlocked, locked := Achievements(global, s.GetAll())
+	if len(unlocked) == 0 {
+		fmt.Fprintln(wr, "No achievements unlocked yet.")
+	}
+	for _, u := range unlocked {
+		if u.Habit == "" {
+			fmt.Fprintf(wr, "%s  %s\n", u.Date.Format(dateLayout), u.Rule.Name)
+			continue
+		}
+		fmt.Fprintf(wr, "%s  %s: '%s'\n", u.Date.Format(dateLayout), u.Rule.Name, u.Habit)
+	}
+	if len(locked) == 0 {
+		return 0
+	}
+	fmt.Fprintln(wr, "Locked:")
+	for _, r := range locked {
+		if r.Description == "" {
+			fmt.Fprintf(wr, "  %s\n", r.Name)
+			continue
+		}
+		fmt.Fprintf(wr, "  %s: %s\n", r.Name, r.Description)
+	}
+	return 0
+}
@@ -0,0 +1,357 @@
+package habit_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/habit"
+)
+
+// days returns completions of days from first to last.
+func days(first, last int) []habit.Completion {
+	var cx []habit.Completion
+	for d := first; d <= last; d++ {
+		cx = append(cx, day(d))
+	}
+	return cx
+}
+
+// loadRules writes rules to a temporary file and loads them.
+func loadRules(t *testing.T, rules string) error {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "achievements.json")
+	if err := os.WriteFile(path, []byte(rules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { habit.LoadRules(filepath.Join(t.TempDir(), "missing.json")) })
+	return habit.LoadRules(path)
+}
+
+func TestLogOn_UnlocksPerfectWeekOnceForAllHabits(t *testing.T) {
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNow(t, day(7).Date)
+	store.Add(habit.Habit{Name: "jog", Date: day(6).Date, Streak: 6, History: days(1, 6)})
+	store.Add(habit.Habit{Name: "read", Date: day(7).Date, Streak: 7, History: days(1, 7)})
+
+	got, err := store.LogOn("jog", day(7).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Achievement unlocked: Perfect week (do every habit 7 days in a row)!\n"
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("want message ending with %q, got %q", want, got)
+	}
+	wantAchievements := []habit.Achievement{{ID: "perfect-week", Date: day(7).Date}}
+	if got := store.GlobalAchievements(); !cmp.Equal(wantAchievements, got) {
+		t.Error(cmp.Diff(wantAchievements, got))
+	}
+	if h, _ := store.Get("jog"); len(h.Achievements) != 0 {
+		t.Errorf("want perfect week stored in the store, not the habit, got %v", h.Achievements)
+	}
+
+	setNow(t, day(8).Date)
+	store.LogOn("read", day(8).Date)
+	got, err = store.LogOn("jog", day(8).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "Achievement unlocked") {
+		t.Errorf("want perfect week unlocked once, got %q", got)
+	}
+}
+
+func TestLogOn_KeepsGlobalAchievementsOfDeletedHabit(t *testing.T) {
+	path := testPath(t)
+	store, err := habit.NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNow(t, day(7).Date)
+	store.Add(habit.Habit{Name: "jog", Date: day(6).Date, Streak: 6, History: days(1, 6)})
+	store.Add(habit.Habit{Name: "read", Date: day(7).Date, Streak: 7, History: days(1, 7)})
+	store.LogOn("jog", day(7).Date)
+	store.Delete("jog")
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = habit.NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []habit.Achievement{{ID: "perfect-week", Date: day(7).Date}}
+	if got := store.GlobalAchievements(); !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	got, err := store.LogOn("read", day(7).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "Achievement unlocked") {
+		t.Errorf("want perfect week not unlocked again, got %q", got)
+	}
+}
+
+func TestLogOn_ChecksStreakOfOtherHabitsOnDayOfLog(t *testing.T) {
+	if err := loadRules(t, `[{"id": "streaker", "name": "Streaker", "when": {"global": true, "streak": 5}}]`); err != nil {
+		t.Fatal(err)
+	}
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNow(t, day(10).Date)
+	store.Add(habit.Habit{Name: "jog", Date: day(5).Date, Streak: 5, History: days(1, 5)})
+
+	got, err := store.LogOn("read", day(10).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "Achievement unlocked") {
+		t.Errorf("want no achievement for streak broken days ago, got %q", got)
+	}
+}
+
+func TestNewFileStore_MovesGlobalAchievementsFromHabitsOfOlderStores(t *testing.T) {
+	path := testPath(t)
+	data := `{"jog": {"name": "jog", "date": "2022-10-07T00:00:00Z", "streak": 7, "achievements": [` +
+		`{"id": "perfect-week", "date": "2022-10-07T00:00:00Z"}, {"id": "century", "date": "2022-10-07T00:00:00Z"}]}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := habit.NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []habit.Achievement{{ID: "perfect-week", Date: day(7).Date}}
+	if got := store.GlobalAchievements(); !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	h, _ := store.Get("jog")
+	want = []habit.Achievement{{ID: "century", Date: day(7).Date}}
+	if !cmp.Equal(want, h.Achievements) {
+		t.Error(cmp.Diff(want, h.Achievements))
+	}
+}
+
+func TestLogOn_UnlocksPerfectWeekIgnoringAbandonedHabits(t *testing.T) {
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNow(t, day(17).Date)
+	store.Add(habit.Habit{Name: "jog", Date: day(16).Date, Streak: 6, History: days(11, 16)})
+	store.Add(habit.Habit{Name: "read", Date: day(17).Date, Streak: 7, History: days(11, 17)})
+	store.Add(habit.Habit{Name: "swim", Date: day(5).Date, Streak: 5, History: days(1, 5)})
+
+	got, err := store.LogOn("jog", day(17).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Achievement unlocked: Perfect week"; !strings.Contains(got, want) {
+		t.Errorf("want message containing %q, got %q", want, got)
+	}
+}
+
+func TestLogOn_OmitsEmptyDescriptionOfAchievement(t *testing.T) {
+	if err := loadRules(t, `[{"id": "first", "name": "First", "when": {"completions": 1}}]`); err != nil {
+		t.Fatal(err)
+	}
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNow(t, day(1).Date)
+
+	got, err := store.LogOn("jog", day(1).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Achievement unlocked: First!\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("want message ending with %q, got %q", want, got)
+	}
+}
+
+func TestLogOn_UnlocksComebackAfterLongBrokenStreak(t *testing.T) {
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNow(t, day(40).Date)
+	store.Add(habit.Habit{Name: "jog", Date: day(30).Date, Streak: 30, History: days(1, 30)})
+	store.Add(habit.Habit{Name: "read", Date: day(30).Date, Streak: 29, History: days(2, 30)})
+
+	got, err := store.LogOn("jog", day(40).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "Achievement unlocked: Comeback") {
+		t.Errorf("want comeback unlocked, got %q", got)
+	}
+	got, err = store.LogOn("read", day(40).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "Achievement unlocked") {
+		t.Errorf("want no comeback after 29-day streak, got %q", got)
+	}
+}
+
+func TestLogOn_UnlocksComebackOfHabitWithFrequencyOnlyAfterLongRest(t *testing.T) {
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNow(t, day(40).Date)
+	store.Add(habit.Habit{Name: "gym", Date: day(30).Date, Streak: 30, History: days(1, 30), Frequency: &habit.Frequency{Times: 3, Days: 7}})
+
+	got, err := store.LogOn("gym", day(34).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "Achievement unlocked") {
+		t.Errorf("want no comeback after 3 days of rest, got %q", got)
+	}
+	got, err = store.LogOn("gym", day(40).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "Achievement unlocked: Comeback") {
+		t.Errorf("want comeback unlocked after 5 days of rest, got %q", got)
+	}
+}
+
+func TestLogOn_UnlocksEarlyBirdByLogsOfAllHabits(t *testing.T) {
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	early := func(d int) habit.Completion {
+		return loggedAt(day(d), day(d).Date.Add(6*time.Hour))
+	}
+	late := func(d int) habit.Completion {
+		return loggedAt(day(d), day(d).Date.Add(7*time.Hour))
+	}
+	store.Add(habit.Habit{Name: "jog", Date: day(5).Date, Streak: 5, History: []habit.Completion{early(1), early(2), early(3), early(4), early(5)}})
+	store.Add(habit.Habit{Name: "read", Date: day(4).Date, Streak: 4, History: []habit.Completion{early(1), early(2), late(3), early(4)}})
+
+	setNow(t, day(5).Date.Add(6*time.Hour))
+	got, err := store.Log("read")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "Early bird") {
+		t.Errorf("want no early bird after 9 early logs, got %q", got)
+	}
+	setNow(t, day(6).Date.Add(5*time.Hour))
+	got, err = store.Log("jog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "Achievement unlocked: Early bird") {
+		t.Errorf("want early bird unlocked, got %q", got)
+	}
+}
+
+func TestLoadRules_AddsAndReplacesRules(t *testing.T) {
+	err := loadRules(t, `[
+		{"id": "week", "name": "Week", "when": {"streak": 7}},
+		{"id": "century", "name": "Fifty", "description": "do a habit 50 times", "when": {"completions": 50}}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, locked := habit.Achievements(nil, nil)
+	var got []string
+	for _, r := range locked {
+		got = append(got, r.ID+" "+r.Name)
+	}
+	want := []string{"century Fifty", "comeback Comeback", "early-bird Early bird", "perfect-week Perfect week", "week Week"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+
+	h := habit.Habit{Name: "jog", Date: day(6).Date, Streak: 6, History: days(1, 6)}
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Add(h)
+	setNow(t, day(7).Date)
+	msg, err := store.Log("jog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(msg, "Achievement unlocked: Week") {
+		t.Errorf("want custom achievement unlocked, got %q", msg)
+	}
+}
+
+func TestLoadRules_ErrorsOnInvalidRulesAndKeepsRulesInUse(t *testing.T) {
+	err := loadRules(t, `[
+		{"id": "Bad ID", "name": "Bad", "when": {"streak": 7}},
+		{"id": "empty", "name": "Empty", "when": {}},
+		{"id": "late", "name": "Late", "when": {"completions": 3, "logged_before": "7am"}}
+	]`)
+	if err == nil {
+		t.Fatal("want error")
+	}
+	for _, want := range []string{`invalid id "Bad ID"`, "rule empty: no requirements", `rule late: invalid logged_before "7am"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("want error containing %q, got %q", want, err)
+		}
+	}
+	if err := loadRules(t, `[{"id": "x", "name": "X", "when": {"streek": 7}}]`); err == nil {
+		t.Error("want error on unknown field")
+	}
+	if _, locked := habit.Achievements(nil, nil); len(locked) != len(habit.DefaultRules) {
+		t.Errorf("want default rules kept, got %v", locked)
+	}
+}
+
+func TestAchievements_ListsGlobalAchievementsOnce(t *testing.T) {
+	t.Parallel()
+
+	hx := []habit.Habit{
+		{Name: "jog", Achievements: []habit.Achievement{{ID: "perfect-week", Date: day(9).Date}, {ID: "century", Date: day(20).Date}}},
+		{Name: "read", Achievements: []habit.Achievement{{ID: "perfect-week", Date: day(7).Date}, {ID: "retired", Date: day(1).Date}}},
+	}
+	unlocked, locked := habit.Achievements(nil, hx)
+	var got []string
+	for _, u := range unlocked {
+		got = append(got, u.Date.Format("2006-01-02")+" "+u.Rule.ID+" "+u.Habit)
+	}
+	want := []string{"2022-10-07 perfect-week ", "2022-10-20 century jog"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if len(locked) != 2 {
+		t.Errorf("want 2 locked rules, got %v", locked)
+	}
+}
+
+func TestMergeHabits_KeepsAchievementsWithEarlierDate(t *testing.T) {
+	t.Parallel()
+
+	laptop := []habit.Habit{{Name: "jog", Date: day(1).Date, Streak: 1, History: []habit.Completion{day(1)},
+		Achievements: []habit.Achievement{{ID: "century", Date: day(9).Date}}}}
+	desktop := []habit.Habit{{Name: "jog", Date: day(1).Date, Streak: 1, History: []habit.Completion{day(1)},
+		Achievements: []habit.Achievement{{ID: "comeback", Date: day(3).Date}, {ID: "century", Date: day(8).Date}}}}
+	want := []habit.Achievement{{ID: "comeback", Date: day(3).Date}, {ID: "century", Date: day(8).Date}}
+	for _, got := range [][]habit.Habit{first(habit.MergeHabits(laptop, desktop)), first(habit.MergeHabits(desktop, laptop))} {
+		if !cmp.Equal(want, got[0].Achievements) {
+			t.Error(cmp.Diff(want, got[0].Achievements))
+		}
+	}
+}
+
+func first(hx []habit.Habit, _ []habit.MergeConflict) []habit.Habit {
+	return hx
+}
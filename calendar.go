@@ -0,0 +1,199 @@
+package habit
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// calendarShades holds cells used to render the heatmap
+// without colours, from "not done" to "all done".
+var calendarShades = []string{"·", "░", "▒", "▓", "█"}
+
+// calendarColors holds ANSI 256 colour codes used to render
+// the heatmap, from "not done" to "all done".
+var calendarColors = []int{237, 22, 28, 34, 40}
+
+// Calendar takes habits and renders a heatmap of days when
+// the habits were done over the given number of weeks ending today.
+//
+// Each row represents a weekday and each column a week. The more
+// habits done on a day, the more intense the cell is.
+// If color is true cells are rendered using ANSI colours.
+func Calendar(hx []Habit, weeks int, color bool) string {
+	return calendar(hx, weeks, color, time.Time{})
+}
+
+// calendar renders the heatmap as Calendar does, putting
+// the cell of the selected day in brackets.
+func calendar(hx []Habit, weeks int, color bool, selected time.Time) string {
+	if weeks < 1 {
+		weeks = 1
+	}
+	done := make(map[time.Time]int)
+	for _, h := range hx {
+		for _, d := range h.Days() {
+			done[d]++
+		}
+	}
+
+	today := RoundDateToDay(Now())
+	start := today.AddDate(0, 0, -int(today.Weekday())-7*(weeks-1))
+
+	var sb strings.Builder
+	sb.WriteString(calendarMonths(start, weeks))
+	for wd := 0; wd < 7; wd++ {
+		label := ""
+		if wd%2 == 1 {
+			label = time.Weekday(wd).String()[:3]
+		}
+		// Cells are separated by spaces, and brackets replace
+		// those around the selected cell, so rows keep their width.
+		row := fmt.Sprintf("%-3s", label)
+		after := " "
+		for w := 0; w < weeks; w++ {
+			day := start.AddDate(0, 0, 7*w+wd)
+			if day.After(today) {
+				break
+			}
+			before := after
+			after = " "
+			if day.Equal(selected) {
+				before, after = "[", "]"
+			}
+			row += before + calendarCell(calendarLevel(done[day], len(hx)), color)
+		}
+		if after == "]" {
+			row += after
+		}
+		sb.WriteString(strings.TrimRight(row, " "))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("    Less")
+	for level := range calendarShades {
+		sb.WriteString(" " + calendarCell(level, color))
+	}
+	sb.WriteString(" More\n")
+	return sb.String()
+}
+
+// calendarMonths returns a header line with abbreviated month
+// names placed above the week in which the month starts.
+func calendarMonths(start time.Time, weeks int) string {
+	line := []byte(strings.Repeat(" ", 4+2*weeks+2))
+	free := 0
+	for w := 0; w < weeks; w++ {
+		day := start.AddDate(0, 0, 7*w)
+		if w > 0 && day.Month() == day.AddDate(0, 0, -7).Month() {
+			continue
+		}
+		pos := 4 + 2*w
+		if pos < free || pos+3 > len(line) {
+			continue
+		}
+		copy(line[pos:], day.Month().String()[:3])
+		free = pos + 4
+	}
+	return strings.TrimRight(string(line), " ") + "\n"
+}
+
+// calendarLevel maps number of habits done on a day
+// to the heatmap intensity level.
+func calendarLevel(done, total int) int {
+	if done == 0 || total == 0 {
+		return 0
+	}
+	top := len(calendarShades) - 1
+	level := (done*top + total - 1) / total
+	if level > top {
+		level = top
+	}
+	return level
+}
+
+func calendarCell(level int, color bool) string {
+	if !color {
+		return calendarShades[level]
+	}
+	return fmt.Sprintf("\x1b[38;5;%dm■\x1b[0m", calendarColors[level])
+}
+
+// isTerminal reports whether v is a file connected to a terminal.
+func isTerminal(v any) bool {
+	f, ok := v.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// parseArgs parses flags which can be interleaved with
+// positional arguments and returns the positional arguments.
+func parseArgs(fset *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fset.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fset.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// selectHabits returns habits from the store. If name is not
+// empty it returns only the habit with the given name.
+func selectHabits(s Store, name string) ([]Habit, error) {
+	habits := s.GetAll()
+	if name == "" {
+		return habits, nil
+	}
+	for _, h := range habits {
+		if h.Name == name {
+			return []Habit{h}, nil
+		}
+	}
+	return nil, fmt.Errorf("habit '%s' is not tracked", name)
+}
+
+func runCalendar(s Store, args []string, wr, ew io.Writer) int {
+	fset := flag.NewFlagSet("calendar", flag.ContinueOnError)
+	fset.SetOutput(ew)
+	weeks := fset.Int("weeks", 52, "number of weeks to show")
+	noColor := fset.Bool("no-color", false, "disable colours")
+	args, err := parseArgs(fset, args)
+	if err != nil {
+		return 1
+	}
+	if *weeks < 1 {
+		fmt.Fprint(ew, errors.New("number of weeks must be positive"))
+		return 1
+	}
+	if len(args) > 1 {
+		fmt.Fprint(ew, errors.New("usage: habit calendar [name] [--weeks 52]"))
+		return 1
+	}
+	var name string
+	if len(args) == 1 {
+		name = args[0]
+	}
+	habits, err := selectHabits(s, name)
+	if err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	color := !*noColor && os.Getenv("NO_COLOR") == "" && isTerminal(wr)
+	fmt.Fprint(wr, Calendar(habits, *weeks, color))
+	return 0
+}
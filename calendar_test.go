@@ -0,0 +1,77 @@
+package habit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/habit"
+)
+
+func day(d int) habit.Completion {
+	return habit.Completion{Date: time.Date(2022, 10, d, 0, 0, 0, 0, time.UTC)}
+}
+
+// setNow makes habit.Now return the given time until the end of the test.
+func setNow(t *testing.T, now time.Time) {
+	t.Helper()
+	orig := habit.Now
+	habit.Now = func() time.Time { return now }
+	t.Cleanup(func() { habit.Now = orig })
+}
+
+func TestCalendar_RendersHeatmapOfCompletedDays(t *testing.T) {
+	setNow(t, time.Date(2022, 10, 12, 8, 0, 0, 0, time.UTC))
+
+	hx := []habit.Habit{
+		{Name: "jog", Date: day(12).Date, Streak: 3, History: []habit.Completion{day(3), day(10), day(11), day(12)}},
+		{Name: "read", Date: day(12).Date, Streak: 1, History: []habit.Completion{day(10), day(12)}},
+	}
+
+	got := habit.Calendar(hx, 2, false)
+	want := "    Oct\n" +
+		"    · ·\n" +
+		"Mon ▒ █\n" +
+		"    · ▒\n" +
+		"Wed · █\n" +
+		"    ·\n" +
+		"Fri ·\n" +
+		"    ·\n" +
+		"    Less · ░ ▒ ▓ █ More\n"
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestCalendar_RendersCurrentStreakOfHabitsWithoutHistory(t *testing.T) {
+	setNow(t, time.Date(2022, 10, 12, 8, 0, 0, 0, time.UTC))
+
+	hx := []habit.Habit{
+		{Name: "jog", Date: day(11).Date, Streak: 3},
+	}
+
+	got := habit.Calendar(hx, 1, false)
+	want := "    Oct\n" +
+		"    █\n" +
+		"Mon █\n" +
+		"    █\n" +
+		"Wed ·\n" +
+		"\n" +
+		"Fri\n" +
+		"\n" +
+		"    Less · ░ ▒ ▓ █ More\n"
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestDays_ReturnsDaysOfCurrentStreakForHabitWithoutHistory(t *testing.T) {
+	t.Parallel()
+
+	h := habit.Habit{Name: "jog", Date: day(11).Date, Streak: 3}
+	got := h.Days()
+	want := []time.Time{day(9).Date, day(10).Date, day(11).Date}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,285 @@
+package habit
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// ChallengeStatus describes how a challenge ended.
+type ChallengeStatus string
+
+const (
+	// ChallengeActive means the challenge hasn't ended yet.
+	ChallengeActive ChallengeStatus = "active"
+	// ChallengeCompleted means the habit was done on every day of the challenge.
+	ChallengeCompleted ChallengeStatus = "completed"
+	// ChallengeFailed means the challenge ended with days not done.
+	ChallengeFailed ChallengeStatus = "failed"
+)
+
+// Challenge represents doing a habit every day for a fixed number of days.
+type Challenge struct {
+	Start  time.Time       `json:"start"`
+	End    time.Time       `json:"end"` // End holds the last day of the challenge.
+	Status ChallengeStatus `json:"status"`
+	Done   int             `json:"done,omitempty"` // Done holds the number of days done, set when the challenge ends.
+}
+
+// Days returns the number of days of the challenge.
+func (c Challenge) Days() int {
+	return DayDiff(c.Start, c.End) + 1
+}
+
+// Day returns the number of the day of the given time in the
+// challenge, starting with 1, capped at the days of the challenge.
+func (c Challenge) Day(t time.Time) int {
+	day := RoundDateToDay(t)
+	if day.Before(c.Start) {
+		return 0
+	}
+	return min(DayDiff(c.Start, day)+1, c.Days())
+}
+
+// StartChallenge starts a challenge of doing the habit every day
+// from the day of start to the day of end. It returns an error if
+// the habit has a challenge going on, if the challenge starts
+// after today or if it ends before it starts.
+func (h *Habit) StartChallenge(start, end time.Time) error {
+	start, end = RoundDateToDay(start), RoundDateToDay(end)
+	if h.Challenge != nil {
+		return fmt.Errorf("habit '%s' has a challenge until %s", h.Name, h.Challenge.End.Format(dateLayout))
+	}
+	if start.After(RoundDateToDay(Now())) {
+		return errors.New("challenge cannot start after today")
+	}
+	if end.Before(start) {
+		return errors.New("challenge cannot end before it starts")
+	}
+	h.Challenge = &Challenge{Start: start, End: end, Status: ChallengeActive}
+	return nil
+}
+
+// daysDone returns the number of days of the challenge the habit was done on.
+func (h *Habit) daysDone(c Challenge) int {
+	n := 0
+	for _, d := range h.Days() {
+		if !d.Before(c.Start) && !d.After(c.End) {
+			n++
+		}
+	}
+	return n
+}
+
+// endedChallenge returns the challenge of the habit with its status
+// on the day of the given time. The challenge is completed once the
+// habit is done on all of its days and failed if it ended without it.
+func (h *Habit) endedChallenge(t time.Time) (Challenge, bool) {
+	if h.Challenge == nil {
+		return Challenge{}, false
+	}
+	c := *h.Challenge
+	c.Done = h.daysDone(c)
+	switch {
+	case c.Done == c.Days():
+		c.Status = ChallengeCompleted
+	case RoundDateToDay(t).After(c.End):
+		c.Status = ChallengeFailed
+	default:
+		return Challenge{}, false
+	}
+	return c, true
+}
+
+// settleChallenge moves the challenge of the habit to past challenges
+// if it ended on the day of the given time. It reports whether it did.
+func (h *Habit) settleChallenge(t time.Time) (Challenge, bool) {
+	c, ok := h.endedChallenge(t)
+	if !ok {
+		return Challenge{}, false
+	}
+	h.PastChallenges = append(h.PastChallenges, c)
+	h.Challenge = nil
+	return c, true
+}
+
+// challengeMessage returns the message about the day of the challenge
+// going on or, on the day it ended and the day after, about its result.
+func (h *Habit) challengeMessage(t time.Time) string {
+	c, ok := h.endedChallenge(t)
+	switch {
+	case !ok && h.Challenge != nil:
+		c = *h.Challenge
+		data := h.messageData(0)
+		data.ChallengeDay, data.ChallengeDays = c.Day(t), c.Days()
+		return message(msgChallengeDay, c.Days(), data, h.Name, data.ChallengeDay, data.ChallengeDays)
+	case !ok && len(h.PastChallenges) > 0:
+		c = h.PastChallenges[len(h.PastChallenges)-1]
+		if RoundDateToDay(t).Before(c.End) || DayDiff(c.End, t) > 1 {
+			return ""
+		}
+	case !ok:
+		return ""
+	}
+	return h.challengeResultMessage(c)
+}
+
+func (h *Habit) challengeResultMessage(c Challenge) string {
+	data := h.messageData(0)
+	data.ChallengeDays, data.ChallengeDone = c.Days(), c.Done
+	if c.Status == ChallengeCompleted {
+		return message(msgChallengeCompleted, c.Days(), data, h.Name, c.Days())
+	}
+	return message(msgChallengeFailed, c.Days(), data, h.Name, c.Done, c.Days())
+}
+
+// SettleChallenges moves challenges ended on the day of the given
+// time to past challenges of their habits. It reports whether any
+// challenge ended.
+//
+// SettleChallenges does not persist data in the store. After
+// calling SettleChallenges(), call Save() to persist data.
+func (f *FileStore) SettleChallenges(t time.Time) bool {
+	settled := false
+	for _, h := range f.GetAll() {
+		if _, ok := h.settleChallenge(t); ok {
+			f.Add(h)
+			settled = true
+		}
+	}
+	return settled
+}
+
+// StartChallenge takes a habit name and starts a challenge of doing
+// the habit from the day of start to the day of end, as
+// Habit.StartChallenge. It returns an error if the habit
+// is not tracked.
+//
+// StartChallenge does not persist data in the store. After
+// calling StartChallenge(), call Save() to persist data.
+func (f *FileStore) StartChallenge(habitName string, start, end time.Time) error {
+	h, ok := f.Get(habitName)
+	if !ok {
+		return fmt.Errorf("habit '%s' is not tracked", habitName)
+	}
+	if err := h.StartChallenge(start, end); err != nil {
+		return err
+	}
+	f.Add(h)
+	return nil
+}
+
+func runChallenge(s *FileStore, args []string, wr, ew io.Writer) int {
+	fset := flag.NewFlagSet("challenge", flag.ContinueOnError)
+	fset.SetOutput(ew)
+	days := fset.Int("days", 0, "number of days of the challenge")
+	until := fset.String("until", "", "last day of the challenge, as YYYY-MM-DD")
+	startFlag := fset.String("start", "", "first day of the challenge, as YYYY-MM-DD, today by default")
+	create := fset.Bool("create", false, "start tracking a new habit even if its name is similar to a tracked habit")
+	args, err := parseArgs(fset, args)
+	if err != nil {
+		return 1
+	}
+	if len(args) != 1 || (*days == 0) == (*until == "") {
+		fmt.Fprint(ew, errors.New("usage: habit challenge <name> --days 30|--until YYYY-MM-DD [--start YYYY-MM-DD] [--create]"))
+		return 1
+	}
+	name := args[0]
+	start := RoundDateToDay(Now())
+	if *startFlag != "" {
+		start, err = time.Parse(dateLayout, *startFlag)
+		if err != nil {
+			fmt.Fprintf(ew, "invalid start date %q, want YYYY-MM-DD", *startFlag)
+			return 1
+		}
+	}
+	var end time.Time
+	switch {
+	case *days < 0:
+		fmt.Fprint(ew, errors.New("number of days must be positive"))
+		return 1
+	case *days > 0:
+		end = start.AddDate(0, 0, *days-1)
+	default:
+		end, err = time.Parse(dateLayout, *until)
+		if err != nil {
+			fmt.Fprintf(ew, "invalid end date %q, want YYYY-MM-DD", *until)
+			return 1
+		}
+	}
+
+	// A challenge can start tracking a new habit,
+	// unless its name looks like a typo of a tracked one.
+	if _, ok := s.Get(name); !ok {
+		if similar, ok := SimilarHabit(s.GetAll(), name); ok && !*create {
+			fmt.Fprint(ew, &SimilarHabitError{Name: name, Similar: similar.Name})
+			return 1
+		}
+		msg, err := s.Log(name)
+		if err != nil {
+			fmt.Fprint(ew, err)
+			return 1
+		}
+		fmt.Fprint(wr, msg)
+	}
+	if err := s.StartChallenge(name, start, end); err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	if err := s.Save(); err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	h, _ := s.Get(name)
+	fmt.Fprintf(wr, "Challenge started: '%s' for %d days, %s to %s\n", name, h.Challenge.Days(), start.Format(dateLayout), end.Format(dateLayout))
+	return 0
+}
+
+func runChallenges(s Store, args []string, wr, ew io.Writer) int {
+	if len(args) > 1 {
+		fmt.Fprint(ew, errors.New("usage: habit challenges [name]"))
+		return 1
+	}
+	name := ""
+	if len(args) == 1 {
+		name = args[0]
+	}
+	hx, err := selectHabits(s, name)
+	if err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	type line struct {
+		habit     string
+		challenge Challenge
+	}
+	var lines []line
+	now := Now()
+	for _, h := range hx {
+		for _, c := range h.PastChallenges {
+			lines = append(lines, line{h.Name, c})
+		}
+		if c, ok := h.endedChallenge(now); ok {
+			lines = append(lines, line{h.Name, c})
+		} else if h.Challenge != nil {
+			lines = append(lines, line{h.Name, *h.Challenge})
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].challenge.Start.Before(lines[j].challenge.Start) })
+	if len(lines) == 0 {
+		fmt.Fprintln(wr, "No challenges yet.")
+	}
+	for _, l := range lines {
+		c := l.challenge
+		fmt.Fprintf(wr, "%s to %s  %s: ", c.Start.Format(dateLayout), c.End.Format(dateLayout), l.habit)
+		if c.Status == ChallengeActive {
+			fmt.Fprintf(wr, "day %d of %d\n", c.Day(now), c.Days())
+			continue
+		}
+		fmt.Fprintf(wr, "%s, done %d of %d days\n", c.Status, c.Done, c.Days())
+	}
+	return 0
+}
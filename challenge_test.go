@@ -0,0 +1,112 @@
+package habit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/habit"
+)
+
+func TestCheck_ReportsDayOfChallenge(t *testing.T) {
+	setNow(t, day(12).Date)
+
+	h := habit.Habit{Name: "jog", Date: day(12).Date, Streak: 12, History: days(1, 12)}
+	if err := h.StartChallenge(day(1).Date, day(30).Date); err != nil {
+		t.Fatal(err)
+	}
+	_, got := h.Check()
+	want := "You're currently on a 12-day streak for 'jog'. Stick to it!\nChallenge 'jog': day 12 of 30.\n"
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestStartChallenge_ErrorsOnInvalidChallenge(t *testing.T) {
+	setNow(t, day(10).Date)
+
+	h := habit.Habit{Name: "jog", Date: day(10).Date, Streak: 1}
+	if err := h.StartChallenge(day(11).Date, day(20).Date); err == nil {
+		t.Error("want error on challenge starting after today")
+	}
+	if err := h.StartChallenge(day(10).Date, day(9).Date); err == nil {
+		t.Error("want error on challenge ending before it starts")
+	}
+	if err := h.StartChallenge(day(10).Date, day(20).Date); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.StartChallenge(day(10).Date, day(20).Date); err == nil {
+		t.Error("want error on second challenge")
+	}
+}
+
+func TestLogOn_CompletesChallengeOnLastDay(t *testing.T) {
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNow(t, day(3).Date)
+	store.Add(habit.Habit{Name: "jog", Date: day(2).Date, Streak: 2, History: days(1, 2)})
+	if err := store.StartChallenge("jog", day(1).Date, day(3).Date); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.LogOn("jog", day(3).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Challenge 'jog' completed: you did it on all 3 days!\n"
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("want message ending with %q, got %q", want, got)
+	}
+	h, _ := store.Get("jog")
+	wantPast := []habit.Challenge{{Start: day(1).Date, End: day(3).Date, Status: habit.ChallengeCompleted, Done: 3}}
+	if h.Challenge != nil || !cmp.Equal(wantPast, h.PastChallenges) {
+		t.Errorf("want challenge moved to past challenges, got %v, %v", h.Challenge, h.PastChallenges)
+	}
+
+	// The habit continues after the challenge.
+	setNow(t, day(4).Date)
+	got, err = store.LogOn("jog", day(4).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Nice work: you've done the habit 'jog' for 4 days in a row now. Keep it up!\n"; want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestSettleChallenges_FailsChallengeEndedWithDaysMissed(t *testing.T) {
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNow(t, day(5).Date)
+	h := habit.Habit{Name: "jog", Date: day(4).Date, Streak: 2, History: []habit.Completion{day(1), day(3), day(4)}}
+	if err := h.StartChallenge(day(1).Date, day(4).Date); err != nil {
+		t.Fatal(err)
+	}
+	store.Add(h)
+
+	if store.SettleChallenges(day(4).Date) {
+		t.Error("want challenge going on until its last day")
+	}
+	if !store.SettleChallenges(day(5).Date) {
+		t.Fatal("want challenge ended")
+	}
+	h, _ = store.Get("jog")
+	wantPast := []habit.Challenge{{Start: day(1).Date, End: day(4).Date, Status: habit.ChallengeFailed, Done: 3}}
+	if !cmp.Equal(wantPast, h.PastChallenges) {
+		t.Error(cmp.Diff(wantPast, h.PastChallenges))
+	}
+	_, got := h.Check()
+	want := "Challenge 'jog' is over: you did it on 3 of 4 days. Try again!\n"
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("want message ending with %q, got %q", want, got)
+	}
+
+	setNow(t, day(7).Date)
+	if _, got := h.Check(); strings.Contains(got, "Challenge") {
+		t.Errorf("want no challenge message days after it ended, got %q", got)
+	}
+}
@@ -0,0 +1,297 @@
+package habit
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// command describes a habit subcommand for shell completion.
+type command struct {
+	Name        string
+	Description string
+	Flags       []string            // Flags holds flags of the command, without leading dashes.
+	FlagValues  map[string][]string // FlagValues holds values completed after flags.
+	FileFlags   []string            // FileFlags holds flags taking a path.
+	Names       bool                // Names reports whether the command takes a habit name.
+	Files       bool                // Files reports whether the command takes paths.
+	Values      []string            // Values holds values of the first positional argument.
+}
+
+var commands = []command{
+	{Name: "new", Description: "start tracking a new habit"},
+	{Name: "log", Description: "record a habit, also one named like a subcommand", Names: true},
+	{Name: "calendar", Description: "render a heatmap of completed days", Flags: []string{"weeks", "no-color"}, Names: true},
+	{Name: "stats", Description: "report statistics of habits", Flags: []string{"json"}, Names: true},
+	{Name: "milestones", Description: "list milestones reached", Flags: []string{"set"}, Names: true},
+	{Name: "achievements", Description: "list achievements unlocked and locked"},
+	{Name: "challenge", Description: "start a challenge of doing a habit every day", Flags: []string{"days", "until", "start", "create"}, Names: true},
+	{Name: "challenges", Description: "list challenges going on and past", Names: true},
+	{Name: "tui", Description: "browse and record habits in an interactive terminal UI"},
+	{Name: "due", Description: "list habits not done today", Flags: []string{"quiet"}},
+	{
+		Name: "status", Description: "print one-line summary for status lines", Flags: []string{"format", "cache"},
+		FlagValues: map[string][]string{"format": {"plain", "tmux", "waybar", "i3blocks"}}, FileFlags: []string{"cache"},
+	},
+	{
+		Name: "export", Description: "export habits to CSV or iCalendar", Flags: []string{"format", "output"},
+		FlagValues: map[string][]string{"format": {"csv", "ics"}}, FileFlags: []string{"output"},
+	},
+	{
+		Name: "import", Description: "import habits from CSV or Loop Habit Tracker", Flags: []string{"mode", "from"},
+		FlagValues: map[string][]string{"mode": {"merge", "replace"}, "from": {"csv", "loop"}}, Files: true,
+	},
+	{
+		Name: "report", Description: "report habits of a week, month or year", Flags: []string{"period", "format", "date", "output"},
+		FlagValues: map[string][]string{"period": {"week", "month", "year"}, "format": {"md", "html"}}, FileFlags: []string{"output"}, Names: true,
+	},
+	{
+		Name: "digest", Description: "send a daily or weekly summary by email", Flags: []string{"to", "from", "period", "smtp", "dry-run"},
+		FlagValues: map[string][]string{"period": {"day", "week"}},
+	},
+	{Name: "merge", Description: "merge habits from another store", Flags: []string{"dry-run"}, Files: true},
+	{Name: "metrics", Description: "print Prometheus metrics", Flags: []string{"output"}, FileFlags: []string{"output"}},
+	{Name: "encrypt", Description: "encrypt the store", Flags: []string{"key-file"}, FileFlags: []string{"key-file"}},
+	{Name: "decrypt", Description: "decrypt the store"},
+	{Name: "serve", Description: "serve habits over HTTP", Flags: []string{"addr"}},
+	{Name: "profiles", Description: "list profiles with their own stores"},
+	{Name: "completion", Description: "print shell completion script", Values: []string{"bash", "zsh", "fish"}},
+}
+
+var completionFuncs = template.FuncMap{
+	"join":      strings.Join,
+	"languages": Languages,
+	"has": func(list []string, s string) bool {
+		for _, v := range list {
+			if v == s {
+				return true
+			}
+		}
+		return false
+	},
+	"dashed": func(flags []string) string {
+		dx := make([]string, len(flags))
+		for i, f := range flags {
+			dx[i] = "--" + f
+		}
+		return strings.Join(dx, " ")
+	},
+}
+
+var bashCompletion = template.Must(template.New("bash").Funcs(completionFuncs).Parse(`# bash completion for habit
+# _habit_names completes names of habits in the store chosen
+# by flags --store and --profile of the completed command.
+_habit_names() {
+    local IFS=$'\n'
+    COMPREPLY=( $(compgen -W "$(habit "${store_flags[@]}" completion names 2>/dev/null)" -- "$1") )
+}
+
+_habit() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local prev="${COMP_WORDS[COMP_CWORD-1]}"
+    case "$prev" in
+        --lang) COMPREPLY=( $(compgen -W "{{join languages " "}}" -- "$cur") ); return ;;
+        --profile) COMPREPLY=( $(compgen -W "$(habit profiles 2>/dev/null | cut -c3-)" -- "$cur") ); return ;;
+        --store) COMPREPLY=( $(compgen -f -- "$cur") ); return ;;
+    esac
+    # Global flags come before the command.
+    local i=1 store_flags=()
+    while [[ $i -lt $COMP_CWORD && ${COMP_WORDS[i]} == -* ]]; do
+        case "${COMP_WORDS[i]}" in
+            --store|--profile) store_flags+=( "${COMP_WORDS[i]}" "${COMP_WORDS[i+1]/#\~/$HOME}" ); i=$((i+2)) ;;
+            --lang) i=$((i+2)) ;;
+            *) i=$((i+1)) ;;
+        esac
+    done
+    if [[ $COMP_CWORD -eq $i ]]; then
+        if [[ $cur == -* ]]; then
+            COMPREPLY=( $(compgen -W "--store --profile --create --lang" -- "$cur") )
+            return
+        fi
+        _habit_names "$cur"
+        COMPREPLY+=( $(compgen -W "{{range $i, $c := .}}{{if $i}} {{end}}{{$c.Name}}{{end}}" -- "$cur") )
+        return
+    fi
+    case "${COMP_WORDS[i]}" in
+{{- range .}}
+        {{.Name}})
+            case "$prev" in
+{{- range $flag, $values := .FlagValues}}
+                --{{$flag}}) COMPREPLY=( $(compgen -W "{{join $values " "}}" -- "$cur") ); return ;;
+{{- end}}
+{{- range .FileFlags}}
+                --{{.}}) COMPREPLY=( $(compgen -f -- "$cur") ); return ;;
+{{- end}}
+            esac
+            if [[ $cur == -* ]]; then
+                COMPREPLY=( $(compgen -W "{{dashed .Flags}}" -- "$cur") )
+{{- if .Names}}
+            else
+                _habit_names "$cur"
+{{- else if .Values}}
+            elif [[ $COMP_CWORD -eq $((i+1)) ]]; then
+                COMPREPLY=( $(compgen -W "{{join .Values " "}}" -- "$cur") )
+{{- else if .Files}}
+            else
+                COMPREPLY=( $(compgen -f -- "$cur") )
+{{- end}}
+            fi
+            ;;
+{{- end}}
+    esac
+}
+
+complete -F _habit habit
+`))
+
+var zshCompletion = template.Must(template.New("zsh").Funcs(completionFuncs).Parse(`#compdef habit
+# zsh completion for habit
+_habit() {
+    local -a names commands store_flags
+    case "${words[CURRENT-1]}" in
+        --lang) compadd -- {{join languages " "}}; return ;;
+        --profile) compadd -- ${(f)"$(habit profiles 2>/dev/null | cut -c3-)"}; return ;;
+        --store) _files; return ;;
+    esac
+    # Global flags come before the command.
+    local i=2
+    while (( i < CURRENT )) && [[ ${words[i]} == -* ]]; do
+        case "${words[i]}" in
+            --store|--profile) store_flags+=( "${words[i]}" "${${(Q)words[i+1]}/#\~/$HOME}" ); (( i += 2 )) ;;
+            --lang) (( i += 2 )) ;;
+            *) (( i++ )) ;;
+        esac
+    done
+    names=("${(@f)$(habit $store_flags completion names 2>/dev/null)}")
+    if (( CURRENT == i )); then
+        if [[ $PREFIX == -* ]]; then
+            compadd -- --store --profile --create --lang
+            return
+        fi
+        commands=(
+{{- range .}}
+            '{{.Name}}:{{.Description}}'
+{{- end}}
+        )
+        _describe 'command' commands
+        compadd -a names
+        return
+    fi
+    case "${words[i]}" in
+{{- range .}}
+        {{.Name}})
+            case "${words[CURRENT-1]}" in
+{{- range $flag, $values := .FlagValues}}
+                --{{$flag}}) compadd -- {{join $values " "}}; return ;;
+{{- end}}
+{{- range .FileFlags}}
+                --{{.}}) _files; return ;;
+{{- end}}
+            esac
+            if [[ $PREFIX == -* ]]; then
+                compadd -- {{dashed .Flags}}
+{{- if .Names}}
+            else
+                compadd -a names
+{{- else if .Values}}
+            elif (( CURRENT == i + 1 )); then
+                compadd -- {{join .Values " "}}
+{{- else if .Files}}
+            else
+                _files
+{{- end}}
+            fi
+            ;;
+{{- end}}
+    esac
+}
+
+compdef _habit habit
+`))
+
+var fishCompletion = template.Must(template.New("fish").Funcs(completionFuncs).Parse(`# fish completion for habit
+# __habit_names prints names of habits in the store chosen
+# by flags --store and --profile of the completed command.
+function __habit_names
+    set -l words (commandline -opc)
+    set -l store_flags
+    set -l i 2
+    while test $i -le (count $words); and string match -q -- '-*' $words[$i]
+        switch $words[$i]
+            case --store --profile
+                set -a store_flags $words[$i] $words[(math $i + 1)]
+                set i (math $i + 2)
+            case --lang
+                set i (math $i + 2)
+            case '*'
+                set i (math $i + 1)
+        end
+    end
+    habit $store_flags completion names 2>/dev/null
+end
+
+complete -c habit -f
+complete -c habit -n __fish_use_subcommand -l store -r -F -d 'path of a file store or URL of a habit server'
+complete -c habit -n __fish_use_subcommand -l profile -x -a '(habit profiles 2>/dev/null | cut -c3-)' -d 'profile whose store to use'
+complete -c habit -n __fish_use_subcommand -l create -d 'start tracking a new habit'
+complete -c habit -n __fish_use_subcommand -l lang -x -a '{{join languages " "}}' -d 'language of messages'
+complete -c habit -n __fish_use_subcommand -a '(__habit_names)' -d 'habit'
+{{- range .}}
+complete -c habit -n __fish_use_subcommand -a {{.Name}} -d '{{.Description}}'
+{{- $c := .}}
+{{- range .Flags}}{{if not (has $c.FileFlags .)}}
+complete -c habit -n '__fish_seen_subcommand_from {{$c.Name}}' -l {{.}}{{with index $c.FlagValues .}} -x -a '{{join . " "}}'{{end}}
+{{- end}}{{end}}
+{{- range .FileFlags}}
+complete -c habit -n '__fish_seen_subcommand_from {{$c.Name}}' -l {{.}} -r -F
+{{- end}}
+{{- if .Names}}
+complete -c habit -n '__fish_seen_subcommand_from {{.Name}}' -a '(__habit_names)'
+{{- end}}
+{{- if .Files}}
+complete -c habit -n '__fish_seen_subcommand_from {{.Name}}' -F
+{{- end}}
+{{- if .Values}}
+complete -c habit -n '__fish_seen_subcommand_from {{.Name}}' -a '{{join .Values " "}}'
+{{- end}}
+{{- end}}
+`))
+
+// WriteCompletion writes completion script for the given shell:
+// bash, zsh or fish. Scripts complete subcommands, flags and
+// names of habits read from the store when completing, the
+// one chosen by flags --store and --profile if given.
+func WriteCompletion(w io.Writer, shell string) error {
+	scripts := map[string]*template.Template{
+		"bash": bashCompletion,
+		"zsh":  zshCompletion,
+		"fish": fishCompletion,
+	}
+	tmpl, ok := scripts[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell: %s", shell)
+	}
+	return tmpl.Execute(w, commands)
+}
+
+// runCompletion prints completion script for the given shell.
+// Completion scripts run `habit completion names` to get
+// names of tracked habits.
+func runCompletion(s Store, args []string, wr, ew io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprint(ew, "usage: habit completion bash|zsh|fish")
+		return 1
+	}
+	if args[0] == "names" {
+		for _, h := range s.GetAll() {
+			fmt.Fprintln(wr, h.Name)
+		}
+		return 0
+	}
+	if err := WriteCompletion(wr, args[0]); err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,84 @@
+package habit_test
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/qba73/habit"
+)
+
+func TestWriteCompletion_WritesScriptCompletingCommandsAndHabitNames(t *testing.T) {
+	t.Parallel()
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var buf bytes.Buffer
+		if err := habit.WriteCompletion(&buf, shell); err != nil {
+			t.Fatal(err)
+		}
+		got := buf.String()
+		for _, want := range []string{"calendar", "weeks", "completion names", "--store", "--profile", "waybar"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: want script containing %q", shell, want)
+			}
+		}
+	}
+}
+
+func TestWriteCompletion_WritesValidBashScript(t *testing.T) {
+	t.Parallel()
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	var buf bytes.Buffer
+	if err := habit.WriteCompletion(&buf, "bash"); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(bash, "-n")
+	cmd.Stdin = &buf
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("invalid bash script: %v: %s", err, out)
+	}
+}
+
+func TestWriteCompletion_WritesBashScriptCompletingNamesFromChosenStore(t *testing.T) {
+	t.Parallel()
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	var buf bytes.Buffer
+	if err := habit.WriteCompletion(&buf, "bash"); err != nil {
+		t.Fatal(err)
+	}
+	// The stand-in habit prints its arguments as the habit names.
+	buf.WriteString(`
+habit() { printf '%s\n' "$@"; }
+COMP_WORDS=(habit --profile work calendar '')
+COMP_CWORD=4
+_habit
+printf '%s\n' "${COMPREPLY[@]}"
+`)
+	cmd := exec.Command(bash)
+	cmd.Stdin = &buf
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	want := "--profile\nwork\ncompletion\nnames\n"
+	if want != string(out) {
+		t.Errorf("want completed names %q, got %q", want, out)
+	}
+}
+
+func TestWriteCompletion_ErrorsOnUnsupportedShell(t *testing.T) {
+	t.Parallel()
+
+	if err := habit.WriteCompletion(&bytes.Buffer{}, "powershell"); err == nil {
+		t.Fatal("want error, got nil")
+	}
+}
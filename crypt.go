@@ -0,0 +1,333 @@
+package habit
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// encryptedMagic starts files of encrypted stores.
+var encryptedMagic = []byte("habit-encrypted-v1\n")
+
+// Kinds of keys encrypted stores are encrypted with.
+const (
+	keyKindPassphrase byte = 1
+	keyKindFile       byte = 2
+)
+
+// Parameters of the scrypt key derivation, as recommended
+// for interactive logins. logN is the base 2 logarithm of N.
+const (
+	scryptLogN    = 15
+	scryptR       = 8
+	scryptP       = 1
+	scryptSaltLen = 16
+	keyLen        = 32
+)
+
+// Key holds the secret encrypted stores are encrypted with:
+// a passphrase or a random key read from a key file.
+type Key struct {
+	passphrase []byte
+	raw        []byte
+}
+
+// PassphraseKey takes a passphrase and returns a key. Encryption
+// keys are derived from the passphrase with scrypt.
+func PassphraseKey(passphrase string) *Key {
+	return &Key{passphrase: []byte(passphrase)}
+}
+
+// GenerateKeyFile creates a key file with a new random key at path.
+// It returns an error if the file already exists.
+func GenerateKeyFile(path string) (*Key, error) {
+	raw := make([]byte, keyLen)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	_, err = fmt.Fprintf(f, "# habit store key, keep it secret and backed up\n%s\n", base64.StdEncoding.EncodeToString(raw))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Key{raw: raw}, nil
+}
+
+// ReadKeyFile reads the key from the key file
+// created by GenerateKeyFile.
+func ReadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(line)
+		if err != nil || len(raw) != keyLen {
+			return nil, fmt.Errorf("%s: invalid key", path)
+		}
+		return &Key{raw: raw}, nil
+	}
+	return nil, fmt.Errorf("%s: missing key", path)
+}
+
+// KeyFromEnv returns the key given by the HABIT_KEY_FILE
+// or the HABIT_PASSPHRASE environment variable, in order
+// of precedence. It returns nil if neither is set.
+func KeyFromEnv() (*Key, error) {
+	if path := os.Getenv("HABIT_KEY_FILE"); path != "" {
+		return ReadKeyFile(path)
+	}
+	if p := os.Getenv("HABIT_PASSPHRASE"); p != "" {
+		return PassphraseKey(p), nil
+	}
+	return nil, nil
+}
+
+// PromptPassphrase is called by NewFileStore to ask for the passphrase
+// of an encrypted store when no key is given in the environment.
+// It's nil by default, so libraries never block on input.
+var PromptPassphrase func() (string, error)
+
+// IsEncrypted reports whether data holds an encrypted store.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedMagic)
+}
+
+// Encrypt takes data and a key and returns the data encrypted
+// with AES-256-GCM. The header, holding the kind of the key and
+// parameters of the key derivation, is authenticated as well.
+func Encrypt(data []byte, key *Key) ([]byte, error) {
+	header := append([]byte{}, encryptedMagic...)
+	var secret []byte
+	if key.raw != nil {
+		header = append(header, keyKindFile)
+		secret = key.raw
+	} else {
+		salt := make([]byte, scryptSaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		header = append(header, keyKindPassphrase, scryptLogN, scryptR, scryptP)
+		header = append(header, salt...)
+		var err error
+		secret, err = scrypt.Key(key.passphrase, salt, 1<<scryptLogN, scryptR, scryptP, keyLen)
+		if err != nil {
+			return nil, err
+		}
+	}
+	aead, err := newAEAD(secret)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append(append([]byte{}, header...), nonce...)
+	return aead.Seal(out, nonce, data, header), nil
+}
+
+// Decrypt takes data encrypted by Encrypt and the key
+// and returns decrypted data. It returns an error if the
+// key is wrong or the data was modified.
+func Decrypt(data []byte, key *Key) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, errors.New("data is not encrypted")
+	}
+	rest := data[len(encryptedMagic):]
+	if len(rest) < 1 {
+		return nil, errors.New("truncated encrypted data")
+	}
+	var secret []byte
+	switch rest[0] {
+	case keyKindFile:
+		if key.raw == nil {
+			return nil, errors.New("data is encrypted with a key file, not a passphrase")
+		}
+		secret = key.raw
+		rest = rest[1:]
+	case keyKindPassphrase:
+		if key.passphrase == nil {
+			return nil, errors.New("data is encrypted with a passphrase, not a key file")
+		}
+		if len(rest) < 4+scryptSaltLen {
+			return nil, errors.New("truncated encrypted data")
+		}
+		logN, r, p, salt := rest[1], rest[2], rest[3], rest[4:4+scryptSaltLen]
+		// Only parameters Encrypt writes are accepted, so a modified
+		// header can't make key derivation take hours or all memory.
+		if logN != scryptLogN || r != scryptR || p != scryptP {
+			return nil, errors.New("unsupported key derivation parameters")
+		}
+		var err error
+		secret, err = scrypt.Key(key.passphrase, salt, 1<<logN, int(r), int(p), keyLen)
+		if err != nil {
+			return nil, err
+		}
+		rest = rest[4+scryptSaltLen:]
+	default:
+		return nil, fmt.Errorf("unsupported key kind %d", rest[0])
+	}
+	header := data[:len(data)-len(rest)]
+	aead, err := newAEAD(secret)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < aead.NonceSize() {
+		return nil, errors.New("truncated encrypted data")
+	}
+	nonce, ciphertext := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return nil, errors.New("wrong key or corrupted data")
+	}
+	return plain, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// storeKey returns the key to decrypt the store at path:
+// from the environment or, if it's not there, by prompting
+// for the passphrase.
+func storeKey(path string) (*Key, error) {
+	key, err := KeyFromEnv()
+	if err != nil || key != nil {
+		return key, err
+	}
+	if PromptPassphrase == nil {
+		return nil, fmt.Errorf("store %s is encrypted, set HABIT_PASSPHRASE or HABIT_KEY_FILE", path)
+	}
+	p, err := PromptPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	return PassphraseKey(p), nil
+}
+
+// terminalPassphrase returns a function reading a passphrase
+// from the terminal without echoing it. The function returns
+// an error if standard input is not a terminal.
+func terminalPassphrase(w io.Writer, prompt string) func() (string, error) {
+	return func() (string, error) {
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) {
+			return "", errors.New("can't read passphrase, standard input is not a terminal: set HABIT_PASSPHRASE or HABIT_KEY_FILE")
+		}
+		fmt.Fprint(w, prompt)
+		p, err := term.ReadPassword(fd)
+		fmt.Fprintln(w)
+		if err != nil {
+			return "", err
+		}
+		return string(p), nil
+	}
+}
+
+func runEncrypt(s *FileStore, args []string, wr, ew io.Writer) int {
+	fset := flag.NewFlagSet("encrypt", flag.ContinueOnError)
+	fset.SetOutput(ew)
+	keyFile := fset.String("key-file", "", "encrypt with the key from the file, generated if it doesn't exist, instead of a passphrase")
+	args, err := parseArgs(fset, args)
+	if err != nil {
+		return 1
+	}
+	if len(args) != 0 {
+		fmt.Fprint(ew, errors.New("usage: habit encrypt [--key-file path]"))
+		return 1
+	}
+	key, err := encryptionKey(*keyFile, wr, ew)
+	if err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	s.Key = key
+	if err := s.Save(); err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	fmt.Fprintf(wr, "Encrypted store %s\n", s.Path)
+	return 0
+}
+
+// encryptionKey returns the key to encrypt the store with: from the
+// key file, generating it if it doesn't exist, from the environment
+// or from the passphrase read twice from the terminal.
+func encryptionKey(keyFile string, wr, ew io.Writer) (*Key, error) {
+	if keyFile != "" {
+		key, err := ReadKeyFile(keyFile)
+		if !errors.Is(err, fs.ErrNotExist) {
+			return key, err
+		}
+		key, err = GenerateKeyFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(wr, "Generated key file %s, keep it secret and backed up\n", keyFile)
+		return key, nil
+	}
+	key, err := KeyFromEnv()
+	if err != nil || key != nil {
+		return key, err
+	}
+	p, err := terminalPassphrase(ew, "New passphrase: ")()
+	if err != nil {
+		return nil, err
+	}
+	if p == "" {
+		return nil, errors.New("passphrase cannot be empty")
+	}
+	again, err := terminalPassphrase(ew, "Repeat passphrase: ")()
+	if err != nil {
+		return nil, err
+	}
+	if p != again {
+		return nil, errors.New("passphrases don't match")
+	}
+	return PassphraseKey(p), nil
+}
+
+func runDecrypt(s *FileStore, args []string, wr, ew io.Writer) int {
+	if len(args) != 0 {
+		fmt.Fprint(ew, errors.New("usage: habit decrypt"))
+		return 1
+	}
+	if s.Key == nil {
+		fmt.Fprintf(ew, "store %s is not encrypted", s.Path)
+		return 1
+	}
+	s.Key = nil
+	if err := s.Save(); err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	fmt.Fprintf(wr, "Decrypted store %s\n", s.Path)
+	return 0
+}
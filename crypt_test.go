@@ -0,0 +1,141 @@
+package habit_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qba73/habit"
+)
+
+func TestEncrypt_DecryptsWithTheSameKey(t *testing.T) {
+	t.Parallel()
+
+	keyFile, err := habit.GenerateKeyFile(filepath.Join(t.TempDir(), "habit.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := map[string]*habit.Key{
+		"passphrase": habit.PassphraseKey("correct horse battery staple"),
+		"key file":   keyFile,
+	}
+	plain := []byte(`{"jog":{"name":"jog"}}`)
+	for name, key := range keys {
+		data, err := habit.Encrypt(plain, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !habit.IsEncrypted(data) || bytes.Contains(data, []byte("jog")) {
+			t.Errorf("%s: want encrypted data, got %q", name, data)
+		}
+		got, err := habit.Decrypt(data, key)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(plain, got) {
+			t.Errorf("%s: want %q, got %q", name, plain, got)
+		}
+	}
+}
+
+func TestDecrypt_ErrorsOnWrongKeyOrModifiedData(t *testing.T) {
+	t.Parallel()
+
+	data, err := habit.Encrypt([]byte("{}"), habit.PassphraseKey("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := habit.Decrypt(data, habit.PassphraseKey("Secret")); err == nil {
+		t.Error("want error on wrong passphrase, got nil")
+	}
+	modified := append([]byte{}, data...)
+	modified[len(modified)-1] ^= 1
+	if _, err := habit.Decrypt(modified, habit.PassphraseKey("secret")); err == nil {
+		t.Error("want error on modified data, got nil")
+	}
+}
+
+func TestDecrypt_ErrorsOnUnsupportedKeyDerivationParameters(t *testing.T) {
+	t.Parallel()
+
+	data, err := habit.Encrypt([]byte("{}"), habit.PassphraseKey("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The header holds the magic, the kind of the key,
+	// then base 2 logarithm of scrypt N, r and p.
+	magic := len("habit-encrypted-v1\n")
+	for i := magic + 1; i < magic+4; i++ {
+		modified := append([]byte{}, data...)
+		modified[i] = 30
+		_, err := habit.Decrypt(modified, habit.PassphraseKey("secret"))
+		if err == nil || !strings.Contains(err.Error(), "unsupported key derivation parameters") {
+			t.Errorf("want error on modified parameter at %d, got %v", i, err)
+		}
+	}
+}
+
+func TestReadKeyFile_ReadsGeneratedKey(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "habit.key")
+	key, err := habit.GenerateKeyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := habit.GenerateKeyFile(path); err == nil {
+		t.Error("want error on overwriting key file, got nil")
+	}
+	read, err := habit.ReadKeyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := habit.Encrypt([]byte("{}"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := habit.Decrypt(data, read); err != nil {
+		t.Errorf("want data decrypted with key read from file, got %v", err)
+	}
+}
+
+func TestNewFileStore_DecryptsStoreWithKeyFromEnv(t *testing.T) {
+	t.Setenv("HABIT_KEY_FILE", "")
+	t.Setenv("HABIT_PASSPHRASE", "secret")
+	path := testPath(t)
+
+	store, err := habit.NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Key = habit.PassphraseKey("secret")
+	store.Add(habit.Habit{Name: "jog", Streak: 1})
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !habit.IsEncrypted(data) {
+		t.Fatalf("want encrypted store, got %q", data)
+	}
+
+	store, err = habit.NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.Get("jog"); !ok {
+		t.Error("want habit 'jog' in decrypted store")
+	}
+	if store.Key == nil {
+		t.Error("want key kept to encrypt the store on save")
+	}
+
+	t.Setenv("HABIT_PASSPHRASE", "")
+	if _, err := habit.NewFileStore(path); err == nil {
+		t.Error("want error on opening encrypted store without key, got nil")
+	}
+}
@@ -0,0 +1,231 @@
+package habit
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvHeader holds columns of habits exported in CSV format.
+var csvHeader = []string{"name", "date", "amount", "note"}
+
+const dateLayout = "2006-01-02"
+
+// ExportCSV takes habits and writes their completions
+// in CSV format, one completion per row.
+func ExportCSV(w io.Writer, hx []Habit) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, h := range hx {
+		for _, c := range h.Completions() {
+			amount := ""
+			if c.Amount != 0 {
+				amount = strconv.FormatFloat(c.Amount, 'f', -1, 64)
+			}
+			if err := cw.Write([]string{h.Name, c.Date.Format(dateLayout), amount, c.Note}); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ImportCSV reads habits and their completions in CSV format
+// as written by ExportCSV. Streaks are rebuilt from completions.
+//
+// It validates all rows and returns an error reporting
+// every invalid line.
+func ImportCSV(r io.Reader) ([]Habit, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("line 1: missing header")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !equalFold(header, csvHeader) {
+		return nil, fmt.Errorf("line 1: invalid header %q, want %q", strings.Join(header, ","), strings.Join(csvHeader, ","))
+	}
+
+	habits := make(map[string]*Habit)
+	var errs []error
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				errs = append(errs, err)
+				continue
+			}
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		name, c, err := parseCSVRecord(record)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+			continue
+		}
+		h, ok := habits[name]
+		if !ok {
+			h = &Habit{Name: name}
+			habits[name] = h
+		}
+		h.History = mergeCompletions(h.History, []Completion{c})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	hx := make([]Habit, 0, len(habits))
+	for _, h := range habits {
+		h.rebuildStreak()
+		hx = append(hx, *h)
+	}
+	sort.Slice(hx, func(i, j int) bool { return hx[i].Name < hx[j].Name })
+	return hx, nil
+}
+
+func parseCSVRecord(record []string) (string, Completion, error) {
+	if len(record) != len(csvHeader) {
+		return "", Completion{}, fmt.Errorf("want %d fields, got %d", len(csvHeader), len(record))
+	}
+	name := strings.TrimSpace(record[0])
+	if name == "" {
+		return "", Completion{}, errors.New("name cannot be empty")
+	}
+	date, err := time.Parse(dateLayout, strings.TrimSpace(record[1]))
+	if err != nil {
+		return "", Completion{}, fmt.Errorf("invalid date %q", record[1])
+	}
+	c := Completion{Date: date, Note: record[3]}
+	if amount := strings.TrimSpace(record[2]); amount != "" {
+		c.Amount, err = strconv.ParseFloat(amount, 64)
+		if err != nil {
+			return "", Completion{}, fmt.Errorf("invalid amount %q", record[2])
+		}
+	}
+	return name, c, nil
+}
+
+func equalFold(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(strings.TrimSpace(a[i]), b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// importCSVFiles imports habits from a CSV file given on the command line.
+func importCSVFiles(paths []string) ([]Habit, error) {
+	if len(paths) != 1 {
+		return nil, errors.New("usage: habit import [--mode merge|replace] file.csv")
+	}
+	f, err := os.Open(paths[0])
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ImportCSV(f)
+}
+
+func runExport(s Store, args []string, wr, ew io.Writer) int {
+	fset := flag.NewFlagSet("export", flag.ContinueOnError)
+	fset.SetOutput(ew)
+	format := fset.String("format", "csv", "export format: csv or ics")
+	output := fset.String("output", "", "write to file instead of standard output")
+	args, err := parseArgs(fset, args)
+	if err != nil {
+		return 1
+	}
+	if len(args) != 0 {
+		fmt.Fprint(ew, errors.New("usage: habit export [--format csv|ics] [--output file]"))
+		return 1
+	}
+	var export func(io.Writer, []Habit) error
+	switch *format {
+	case "csv":
+		export = ExportCSV
+	case "ics":
+		export = ExportICS
+	default:
+		fmt.Fprintf(ew, "unsupported export format: %s", *format)
+		return 1
+	}
+
+	w := wr
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprint(ew, err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := export(w, s.GetAll()); err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	return 0
+}
+
+func runImport(s *FileStore, args []string, wr, ew io.Writer) int {
+	fset := flag.NewFlagSet("import", flag.ContinueOnError)
+	fset.SetOutput(ew)
+	mode := fset.String("mode", "merge", "how to combine imported habits with the store: merge or replace")
+	from := fset.String("from", "csv", "format of imported data: csv or loop")
+	args, err := parseArgs(fset, args)
+	if err != nil {
+		return 1
+	}
+	var importMode ImportMode
+	switch *mode {
+	case "merge":
+		importMode = ImportMerge
+	case "replace":
+		importMode = ImportReplace
+	default:
+		fmt.Fprintf(ew, "unsupported import mode: %s", *mode)
+		return 1
+	}
+
+	var hx []Habit
+	switch *from {
+	case "csv":
+		hx, err = importCSVFiles(args)
+	case "loop":
+		hx, err = importLoopFiles(args)
+	default:
+		err = fmt.Errorf("unsupported import format: %s", *from)
+	}
+	if err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	s.Import(hx, importMode)
+	if err := s.Save(); err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	fmt.Fprintf(wr, "Imported habits: %d\n", len(hx))
+	return 0
+}
@@ -0,0 +1,132 @@
+package habit_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/habit"
+)
+
+func TestExportCSV_WritesCompletionsOfAllHabits(t *testing.T) {
+	t.Parallel()
+
+	hx := []habit.Habit{
+		{Name: "jog", Date: day(4).Date, Streak: 2, History: []habit.Completion{
+			{Date: day(3).Date, Amount: 5.5, Note: "park, sunny"},
+			day(4),
+		}},
+		{Name: "read", Date: day(4).Date, Streak: 1, History: []habit.Completion{day(4)}},
+	}
+
+	var buf bytes.Buffer
+	if err := habit.ExportCSV(&buf, hx); err != nil {
+		t.Fatal(err)
+	}
+	want := "name,date,amount,note\n" +
+		"jog,2022-10-03,5.5,\"park, sunny\"\n" +
+		"jog,2022-10-04,,\n" +
+		"read,2022-10-04,,\n"
+	got := buf.String()
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestImportCSV_RoundTripsExportedHabits(t *testing.T) {
+	t.Parallel()
+
+	want := []habit.Habit{
+		{Name: "jog", Date: day(5).Date, Streak: 2, History: []habit.Completion{
+			{Date: day(1).Date, Amount: 5.5, Note: "park, sunny"},
+			day(4),
+			day(5),
+		}},
+		{Name: "read", Date: day(4).Date, Streak: 1, History: []habit.Completion{day(4)}},
+	}
+
+	var buf bytes.Buffer
+	if err := habit.ExportCSV(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := habit.ImportCSV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestImportCSV_ReportsInvalidLines(t *testing.T) {
+	t.Parallel()
+
+	data := "name,date,amount,note\n" +
+		"jog,2022-10-03,,\n" +
+		",2022-10-03,,\n" +
+		"jog,03/10/2022,,\n" +
+		"jog,2022-10-04,many,\n"
+	_, err := habit.ImportCSV(strings.NewReader(data))
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+	for _, want := range []string{"line 3: name cannot be empty", "line 4: invalid date", "line 5: invalid amount"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("want error containing %q, got %q", want, err)
+		}
+	}
+}
+
+func TestImportCSV_ErrorsOnInvalidHeader(t *testing.T) {
+	t.Parallel()
+
+	_, err := habit.ImportCSV(strings.NewReader("habit,day\njog,2022-10-03\n"))
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+}
+
+func TestImport_MergesCompletionsIntoExistingHabits(t *testing.T) {
+	t.Parallel()
+
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Add(habit.Habit{Name: "jog", Date: day(4).Date, Streak: 1, History: []habit.Completion{day(4)}})
+	store.Add(habit.Habit{Name: "read", Date: day(4).Date, Streak: 1, History: []habit.Completion{day(4)}})
+
+	store.Import([]habit.Habit{
+		{Name: "jog", Date: day(3).Date, Streak: 1, History: []habit.Completion{day(3)}},
+	}, habit.ImportMerge)
+
+	got := store.GetAll()
+	want := []habit.Habit{
+		{Name: "jog", Date: day(4).Date, Streak: 2, History: []habit.Completion{day(3), day(4)}},
+		{Name: "read", Date: day(4).Date, Streak: 1, History: []habit.Completion{day(4)}},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestImport_ReplacesHabitsInStore(t *testing.T) {
+	t.Parallel()
+
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Add(habit.Habit{Name: "read", Date: day(4).Date, Streak: 1, History: []habit.Completion{day(4)}})
+
+	imported := []habit.Habit{
+		{Name: "jog", Date: day(3).Date, Streak: 1, History: []habit.Completion{day(3)}},
+	}
+	store.Import(imported, habit.ImportReplace)
+
+	got := store.GetAll()
+	if !cmp.Equal(imported, got) {
+		t.Error(cmp.Diff(imported, got))
+	}
+}
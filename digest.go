@@ -0,0 +1,223 @@
+package habit
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// Digest holds an email summarising habits.
+type Digest struct {
+	Subject string
+	Body    string
+}
+
+// digestPeriods holds periods digests summarise.
+var digestPeriods = []string{"day", "week"}
+
+// NewDigest takes a store and the period to summarise, day or week,
+// and returns the digest. The daily digest reports streaks and
+// habits due today. The weekly digest holds the report of the week
+// holding yesterday, so it covers the past week when sent on Monday.
+func NewDigest(s Store, period string) (Digest, error) {
+	today := RoundDateToDay(Now())
+	switch period {
+	case "day":
+		var sb strings.Builder
+		sb.WriteString(Check(s))
+		due := Due(s)
+		if len(due) == 0 && len(s.GetAll()) > 0 {
+			sb.WriteString("\n" + message(msgAllDone, 0, MessageData{}))
+		}
+		if len(due) > 0 {
+			sb.WriteString("\nDue today:\n")
+		}
+		for _, h := range due {
+			sb.WriteString("- " + dueLine(h))
+		}
+		return Digest{Subject: "Habit digest: " + today.Format(dateLayout), Body: sb.String()}, nil
+	case "week":
+		r, err := NewReport(s.GetAll(), "week", today.AddDate(0, 0, -1))
+		if err != nil {
+			return Digest{}, err
+		}
+		var sb strings.Builder
+		if err := WriteReportMarkdown(&sb, r); err != nil {
+			return Digest{}, err
+		}
+		return Digest{Subject: "Habit digest: " + r.Title, Body: sb.String()}, nil
+	}
+	return Digest{}, fmt.Errorf("unsupported digest period: %s, want one of: %s", period, strings.Join(digestPeriods, ", "))
+}
+
+// Message returns the digest as a plain text email message
+// from and to the given addresses, sent at the given time.
+func (d Digest) Message(from *mail.Address, to []*mail.Address, date time.Time) []byte {
+	var b bytes.Buffer
+	recipients := make([]string, len(to))
+	for i, a := range to {
+		recipients[i] = a.String()
+	}
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", d.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qw := quotedprintable.NewWriter(&b)
+	qw.Write([]byte(strings.ReplaceAll(d.Body, "\n", "\r\n")))
+	qw.Close()
+	return b.Bytes()
+}
+
+// Mailer sends digests through an SMTP server. The connection is
+// upgraded with STARTTLS whenever the server supports it, and
+// credentials, if given, are sent only over TLS or to localhost.
+type Mailer struct {
+	Addr      string // Addr holds host:port of the SMTP server.
+	Username  string
+	Password  string
+	TLSConfig *tls.Config   // TLSConfig configures STARTTLS. Nil verifies the server against system roots.
+	Timeout   time.Duration // Timeout limits the whole delivery, one minute if zero.
+}
+
+// Send delivers the digest from and to the given addresses.
+func (m *Mailer) Send(from *mail.Address, to []*mail.Address, d Digest) error {
+	if len(to) == 0 {
+		return errors.New("no recipients")
+	}
+	host, _, err := net.SplitHostPort(m.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP server address %q, want host:port", m.Addr)
+	}
+	timeout := m.Timeout
+	if timeout == 0 {
+		timeout = time.Minute
+	}
+	conn, err := net.DialTimeout("tcp", m.Addr, timeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		cfg := &tls.Config{ServerName: host}
+		if m.TLSConfig != nil {
+			cfg = m.TLSConfig.Clone()
+			if cfg.ServerName == "" {
+				cfg.ServerName = host
+			}
+		}
+		if err := c.StartTLS(cfg); err != nil {
+			return fmt.Errorf("starting TLS: %w", err)
+		}
+	}
+	if m.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("SMTP server doesn't support authentication")
+		}
+		if err := c.Auth(smtp.PlainAuth("", m.Username, m.Password, host)); err != nil {
+			return fmt.Errorf("authenticating: %w", err)
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, a := range to {
+		if err := c.Rcpt(a.Address); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(d.Message(from, to, Now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// MailerFromEnv returns the mailer configured by the environment
+// variables HABIT_SMTP_ADDR, localhost:25 if it's not set,
+// HABIT_SMTP_USER and HABIT_SMTP_PASSWORD.
+func MailerFromEnv() *Mailer {
+	m := &Mailer{
+		Addr:     os.Getenv("HABIT_SMTP_ADDR"),
+		Username: os.Getenv("HABIT_SMTP_USER"),
+		Password: os.Getenv("HABIT_SMTP_PASSWORD"),
+	}
+	if m.Addr == "" {
+		m.Addr = "localhost:25"
+	}
+	return m
+}
+
+func runDigest(s Store, args []string, wr, ew io.Writer) int {
+	fset := flag.NewFlagSet("digest", flag.ContinueOnError)
+	fset.SetOutput(ew)
+	toFlag := fset.String("to", "", "comma separated addresses to send the digest to")
+	fromFlag := fset.String("from", os.Getenv("HABIT_SMTP_FROM"), "address to send the digest from, the first recipient by default")
+	period := fset.String("period", "day", "period to summarise: day or week")
+	smtpAddr := fset.String("smtp", "", "host:port of the SMTP server, instead of HABIT_SMTP_ADDR")
+	dryRun := fset.Bool("dry-run", false, "print the digest instead of sending it")
+	args, err := parseArgs(fset, args)
+	if err != nil {
+		return 1
+	}
+	if len(args) != 0 || *toFlag == "" {
+		fmt.Fprint(ew, errors.New("usage: habit digest --to address [--period day|week] [--from address] [--smtp host:port] [--dry-run]"))
+		return 1
+	}
+	to, err := mail.ParseAddressList(*toFlag)
+	if err != nil {
+		fmt.Fprintf(ew, "invalid recipient %q: %v", *toFlag, err)
+		return 1
+	}
+	from := to[0]
+	if *fromFlag != "" {
+		from, err = mail.ParseAddress(*fromFlag)
+		if err != nil {
+			fmt.Fprintf(ew, "invalid sender %q: %v", *fromFlag, err)
+			return 1
+		}
+	}
+	d, err := NewDigest(s, *period)
+	if err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	if *dryRun {
+		fmt.Fprintf(wr, "Subject: %s\n\n%s", d.Subject, d.Body)
+		return 0
+	}
+	m := MailerFromEnv()
+	if *smtpAddr != "" {
+		m.Addr = *smtpAddr
+	}
+	if err := m.Send(from, to, d); err != nil {
+		fmt.Fprintf(ew, "sending digest through %s: %v", m.Addr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,216 @@
+package habit_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"net"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/habit"
+)
+
+// smtpMessage holds a message received by the SMTP stand-in.
+type smtpMessage struct {
+	From   string
+	To     []string
+	Data   string
+	Secure bool // Secure reports whether the message was sent over TLS.
+	Authed bool
+}
+
+// smtpStandIn is an in-process SMTP server supporting STARTTLS and
+// AUTH PLAIN. It returns the address it listens on, the TLS config
+// trusting its certificate and a channel receiving each message.
+func smtpStandIn(t *testing.T, user, pass string) (string, *tls.Config, <-chan smtpMessage) {
+	t.Helper()
+	hs := httptest.NewUnstartedServer(nil)
+	hs.StartTLS()
+	serverTLS := &tls.Config{Certificates: hs.TLS.Certificates}
+	roots := x509.NewCertPool()
+	roots.AddCert(hs.Certificate())
+	hs.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	msgs := make(chan smtpMessage, 1)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, serverTLS, user, pass, msgs)
+		}
+	}()
+	return l.Addr().String(), &tls.Config{RootCAs: roots}, msgs
+}
+
+func serveSMTP(conn net.Conn, serverTLS *tls.Config, user, pass string, msgs chan<- smtpMessage) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	var msg smtpMessage
+	tp.PrintfLine("220 stand-in ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-stand-in")
+			if !msg.Secure {
+				tp.PrintfLine("250-STARTTLS")
+			}
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			tp.PrintfLine("220 ready to start TLS")
+			tc := tls.Server(conn, serverTLS)
+			if err := tc.Handshake(); err != nil {
+				return
+			}
+			conn, tp, msg.Secure = tc, textproto.NewConn(tc), true
+		case "AUTH":
+			creds, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+			if string(creds) != "\x00"+user+"\x00"+pass {
+				tp.PrintfLine("535 authentication failed")
+				continue
+			}
+			msg.Authed = true
+			tp.PrintfLine("235 authenticated")
+		case "MAIL":
+			msg.From = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			msg.To = append(msg.To, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = string(data)
+			tp.PrintfLine("250 queued")
+			msgs <- msg
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+func digestStore(t *testing.T) *habit.FileStore {
+	t.Helper()
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Add(habit.Habit{Name: "jog", Date: day(12).Date, Streak: 12, History: days(1, 12)})
+	store.Add(habit.Habit{Name: "read", Date: day(11).Date, Streak: 2, History: days(10, 11)})
+	return store
+}
+
+func TestNewDigest_ReportsStreaksAndHabitsDueToday(t *testing.T) {
+	setNow(t, day(12).Date)
+
+	d, err := habit.NewDigest(digestStore(t), "day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := habit.Digest{
+		Subject: "Habit digest: 2022-10-12",
+		Body: "You're currently on a 12-day streak for 'jog'. Stick to it!\n" +
+			"You're currently on a 2-day streak for 'read'. Stick to it!\n" +
+			"\nDue today:\n" +
+			"- read (2-day streak at risk)\n",
+	}
+	if !cmp.Equal(want, d) {
+		t.Error(cmp.Diff(want, d))
+	}
+}
+
+func TestNewDigest_ReportsPastWeek(t *testing.T) {
+	setNow(t, day(10).Date) // Monday
+
+	d, err := habit.NewDigest(digestStore(t), "week")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Habit digest: Week 40 of 2022, 2022-10-03 to 2022-10-09"; want != d.Subject {
+		t.Error(cmp.Diff(want, d.Subject))
+	}
+	if want := "## jog\n\n- Completions: 7 of 7 days (100%)\n"; !strings.Contains(d.Body, want) {
+		t.Errorf("want body containing %q, got:\n%s", want, d.Body)
+	}
+	if _, err := habit.NewDigest(digestStore(t), "month"); err == nil {
+		t.Error("want error on unsupported period")
+	}
+}
+
+func TestMailerSend_DeliversDigestOverTLSWithAuth(t *testing.T) {
+	setNow(t, day(12).Date)
+
+	addr, tlsConfig, msgs := smtpStandIn(t, "me", "secret")
+	m := &habit.Mailer{Addr: addr, Username: "me", Password: "secret", TLSConfig: tlsConfig}
+	from := &mail.Address{Name: "Habit", Address: "habit@example.com"}
+	to := []*mail.Address{{Address: "me@example.com"}, {Address: "coach@example.com"}}
+	d := habit.Digest{Subject: "Habit digest: 2022-10-12", Body: "Zażółć gęślą jaźń\n"}
+	if err := m.Send(from, to, d); err != nil {
+		t.Fatal(err)
+	}
+	msg := <-msgs
+	if !msg.Secure || !msg.Authed {
+		t.Errorf("want message sent over TLS after authenticating, got secure %t, authenticated %t", msg.Secure, msg.Authed)
+	}
+	if msg.From != "habit@example.com" || !cmp.Equal([]string{"me@example.com", "coach@example.com"}, msg.To) {
+		t.Errorf("want envelope from habit@example.com to both recipients, got %q to %q", msg.From, msg.To)
+	}
+	parsed, err := mail.ReadMessage(strings.NewReader(msg.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for header, want := range map[string]string{
+		"From":    `"Habit" <habit@example.com>`,
+		"To":      "<me@example.com>, <coach@example.com>",
+		"Subject": "Habit digest: 2022-10-12",
+		"Date":    "Wed, 12 Oct 2022 00:00:00 +0000",
+	} {
+		if got := parsed.Header.Get(header); want != got {
+			t.Errorf("want %s header %q, got %q", header, want, got)
+		}
+	}
+	if want := "Za=C5=BC=C3=B3=C5=82=C4=87 g=C4=99=C5=9Bl=C4=85 ja=C5=BA=C5=84\n"; !strings.Contains(msg.Data, want) {
+		t.Errorf("want quoted-printable body containing %q, got:\n%s", want, msg.Data)
+	}
+}
+
+func TestMailerSend_ErrorsOnRejectedCredentials(t *testing.T) {
+	addr, tlsConfig, _ := smtpStandIn(t, "me", "secret")
+	m := &habit.Mailer{Addr: addr, Username: "me", Password: "wrong", TLSConfig: tlsConfig}
+	to := []*mail.Address{{Address: "me@example.com"}}
+	err := m.Send(to[0], to, habit.Digest{Subject: "digest"})
+	if err == nil || !strings.Contains(err.Error(), "535") {
+		t.Errorf("want authentication error, got %v", err)
+	}
+}
+
+func TestMailerSend_ErrorsOnUntrustedCertificate(t *testing.T) {
+	addr, _, _ := smtpStandIn(t, "me", "secret")
+	m := &habit.Mailer{Addr: addr, Username: "me", Password: "secret"}
+	to := []*mail.Address{{Address: "me@example.com"}}
+	if err := m.Send(to[0], to, habit.Digest{Subject: "digest"}); err == nil {
+		t.Error("want error on certificate not signed by a trusted authority")
+	}
+}
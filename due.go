@@ -0,0 +1,98 @@
+package habit
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Due reports whether the habit still needs to be done today.
+//
+// A habit done today is not due. A habit with a frequency is not
+// due once it was done the required number of times in the period
+// ending today, for example 3 times in the last 7 days.
+func (h *Habit) Due() bool {
+	today := RoundDateToDay(Now())
+	if h.DoneOn(today) {
+		return false
+	}
+	if h.Frequency == nil || h.Frequency.Days < 1 {
+		return true
+	}
+	from := today.AddDate(0, 0, -h.Frequency.Days+1)
+	done := 0
+	for _, d := range h.Days() {
+		if !d.Before(from) && !d.After(today) {
+			done++
+		}
+	}
+	return done < h.Frequency.Times
+}
+
+// Due takes a store and returns habits due today, ordered by length
+// of the streak at risk, longest first, and then by name.
+func Due(s Store) []Habit {
+	type due struct {
+		habit  Habit
+		streak int
+	}
+	var dx []due
+	for _, h := range s.GetAll() {
+		if h.Due() {
+			dx = append(dx, due{habit: h, streak: h.Stats().CurrentStreak})
+		}
+	}
+	sort.SliceStable(dx, func(i, j int) bool { return dx[i].streak > dx[j].streak })
+	hx := make([]Habit, 0, len(dx))
+	for _, d := range dx {
+		hx = append(hx, d.habit)
+	}
+	return hx
+}
+
+// runDue prints habits due today. It exits with status 0
+// when all habits are done and 1 otherwise, so it can be
+// used in shell prompts and cron reminders.
+func runDue(s Store, args []string, wr, ew io.Writer) int {
+	fset := flag.NewFlagSet("due", flag.ContinueOnError)
+	fset.SetOutput(ew)
+	quiet := fset.Bool("quiet", false, "print nothing, only set the exit status")
+	args, err := parseArgs(fset, args)
+	if err != nil {
+		return 2
+	}
+	if len(args) != 0 {
+		fmt.Fprint(ew, errors.New("usage: habit due [--quiet]"))
+		return 2
+	}
+	habits := Due(s)
+	if len(habits) == 0 {
+		if !*quiet && len(s.GetAll()) == 0 {
+			fmt.Fprint(wr, message(msgNotTracking, 0, MessageData{}))
+			return 0
+		}
+		if !*quiet {
+			fmt.Fprint(wr, message(msgAllDone, 0, MessageData{}))
+		}
+		return 0
+	}
+	if *quiet {
+		return 1
+	}
+	for _, h := range habits {
+		fmt.Fprint(wr, dueLine(h))
+	}
+	return 1
+}
+
+// dueLine returns the line listing the habit due today, with
+// the streak at risk if the habit is on one.
+func dueLine(h Habit) string {
+	streak := h.Stats().CurrentStreak
+	if streak == 0 {
+		return h.Name + "\n"
+	}
+	return translate(msgStreakRisk, streak, h.Name, streak)
+}
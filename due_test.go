@@ -0,0 +1,68 @@
+package habit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/habit"
+)
+
+func TestDue_ReportsWhetherHabitNeedsToBeDoneToday(t *testing.T) {
+	setNow(t, time.Date(2022, 10, 12, 8, 0, 0, 0, time.UTC))
+
+	tt := []struct {
+		name  string
+		habit habit.Habit
+		want  bool
+	}{
+		{
+			name:  "done today",
+			habit: habit.Habit{Name: "jog", Date: day(12).Date, Streak: 1, History: []habit.Completion{day(12)}},
+			want:  false,
+		},
+		{
+			name:  "done yesterday",
+			habit: habit.Habit{Name: "jog", Date: day(11).Date, Streak: 1, History: []habit.Completion{day(11)}},
+			want:  true,
+		},
+		{
+			name: "weekly quota met",
+			habit: habit.Habit{Name: "gym", Date: day(10).Date, Streak: 1, History: []habit.Completion{day(6), day(8), day(10)},
+				Frequency: &habit.Frequency{Times: 3, Days: 7}},
+			want: false,
+		},
+		{
+			name: "weekly quota not met",
+			habit: habit.Habit{Name: "gym", Date: day(10).Date, Streak: 1, History: []habit.Completion{day(5), day(8), day(10)},
+				Frequency: &habit.Frequency{Times: 3, Days: 7}},
+			want: true,
+		},
+	}
+	for _, tc := range tt {
+		if got := tc.habit.Due(); tc.want != got {
+			t.Errorf("%s: want %t, got %t", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestDue_OrdersHabitsByStreakAtRisk(t *testing.T) {
+	setNow(t, time.Date(2022, 10, 12, 8, 0, 0, 0, time.UTC))
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Add(habit.Habit{Name: "jog", Date: day(11).Date, Streak: 2, History: []habit.Completion{day(10), day(11)}})
+	store.Add(habit.Habit{Name: "read", Date: day(12).Date, Streak: 1, History: []habit.Completion{day(12)}})
+	store.Add(habit.Habit{Name: "swim", Date: day(1).Date, Streak: 1, History: []habit.Completion{day(1)}})
+	store.Add(habit.Habit{Name: "write", Date: day(11).Date, Streak: 5, History: []habit.Completion{day(7), day(8), day(9), day(10), day(11)}})
+
+	var got []string
+	for _, h := range habit.Due(store) {
+		got = append(got, h.Name)
+	}
+	want := []string{"write", "jog", "swim"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
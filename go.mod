@@ -5,7 +5,10 @@ go 1.21
 require (
 	github.com/google/go-cmp v0.5.8
 	github.com/rogpeppe/go-internal v1.9.1-0.20230209130841-f0583b8402aa
+	golang.org/x/crypto v0.21.0
 	golang.org/x/exp v0.0.0-20230113152452-c42ee1cf562e
+	golang.org/x/sys v0.18.0
+	golang.org/x/term v0.18.0
 )
 
 require github.com/pkg/diff v0.0.0-20210226163009-20ebb0f2a09e // indirect
@@ -1,12 +1,12 @@
 package habit
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"io/fs"
 	"math"
 	"os"
 	"path/filepath"
@@ -32,7 +32,45 @@ type Store interface {
 type Habit struct {
 	Name   string    `json:"name"`
 	Date   time.Time `json:"date"`   // Date it's a date when habit activity was last recorded
-	Streak int       `json:"streak"` // Streak represents number of consecutive days when habit was recorded.
+	Streak int       `json:"streak"` // Streak represents number of consecutive days when habit was recorded, allowing rest days of habits with a frequency.
+
+	History    []Completion `json:"history,omitempty"`    // History holds days when the habit was done, oldest first.
+	Frequency  *Frequency   `json:"frequency,omitempty"`  // Frequency holds how often the habit should be done. Nil means every day.
+	Milestones []int        `json:"milestones,omitempty"` // Milestones holds streak lengths celebrated as milestones. Nil means DefaultMilestones.
+
+	Achievements []Achievement `json:"achievements,omitempty"` // Achievements holds achievements unlocked when the habit was recorded.
+
+	Challenge      *Challenge  `json:"challenge,omitempty"`       // Challenge holds the challenge going on, if there is one.
+	PastChallenges []Challenge `json:"past_challenges,omitempty"` // PastChallenges holds ended challenges, oldest first.
+}
+
+// Frequency represents how often a habit should be done:
+// given number of times in every period of given number of days.
+type Frequency struct {
+	Times int `json:"times"`
+	Days  int `json:"days"`
+}
+
+// gap returns the most days allowed between two days the habit is
+// done on for the streak to go on: 1 for daily habits and Days-Times+1
+// for habits done Times in every Days days, so a habit done 5 times a
+// week keeps its streak over the weekend and one done 3 times a week
+// can rest up to 4 days in a row.
+func (f *Frequency) gap() int {
+	if f == nil || f.Times < 1 || f.Times >= f.Days {
+		return 1
+	}
+	return f.Days - f.Times + 1
+}
+
+// Completion represents a single day when the habit was done.
+type Completion struct {
+	Date   time.Time `json:"date"`
+	Amount float64   `json:"amount,omitempty"` // Amount holds optional quantity, for example kilometers or pages.
+	Note   string    `json:"note,omitempty"`
+
+	Milestone int        `json:"milestone,omitempty"` // Milestone holds the streak length reached on the day, if it's a milestone.
+	LoggedAt  *time.Time `json:"logged_at,omitempty"` // LoggedAt holds the time, in the local zone, the day was logged. It's nil for imported and backfilled days.
 }
 
 // New takes a name and returns a new habit.
@@ -51,17 +89,131 @@ func New(name string) (Habit, error) {
 
 // Start starts a new streak.
 func (h *Habit) Start() string {
-	h.startNewStreak()
-	return fmt.Sprintf("Good luck with your new habit '%s'. Don't forget to do it tomorrow.\n", h.Name)
+	return h.start(Now())
+}
+
+func (h *Habit) start(t time.Time) string {
+	h.startNewStreak(t)
+	return message(msgStart, 0, h.messageData(0), h.Name)
 }
 
-func (h *Habit) startNewStreak() {
-	h.setDay(Now())
+func (h *Habit) startNewStreak(t time.Time) {
+	h.setDay(t)
 	h.resetStreak()
 }
 
 func (h *Habit) setDay(t time.Time) {
+	if len(h.History) == 0 {
+		h.History = h.derivedHistory()
+	}
 	h.Date = RoundDateToDay(t)
+	h.addCompletion(h.Date, t)
+}
+
+func (h *Habit) addCompletion(day, loggedAt time.Time) {
+	n := len(h.History)
+	if n > 0 && h.History[n-1].Date.Equal(day) {
+		if h.History[n-1].LoggedAt == nil {
+			h.History[n-1].LoggedAt = &loggedAt
+		}
+		return
+	}
+	h.History = append(h.History, Completion{Date: day, LoggedAt: &loggedAt})
+}
+
+// derivedHistory rebuilds completions of the current streak
+// for habits stored before the history was recorded.
+func (h *Habit) derivedHistory() []Completion {
+	var cx []Completion
+	for i := h.Streak - 1; i >= 0; i-- {
+		cx = append(cx, Completion{Date: RoundDateToDay(h.Date.AddDate(0, 0, -i))})
+	}
+	return cx
+}
+
+// Days returns days when the habit was done, oldest first.
+//
+// Habits stored before the history was recorded report
+// days of their current streak.
+func (h *Habit) Days() []time.Time {
+	cx := h.History
+	if len(cx) == 0 {
+		cx = h.derivedHistory()
+	}
+	days := make([]time.Time, 0, len(cx))
+	for _, c := range cx {
+		days = append(days, RoundDateToDay(c.Date))
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	unique := days[:0]
+	for i, d := range days {
+		if i > 0 && d.Equal(days[i-1]) {
+			continue
+		}
+		unique = append(unique, d)
+	}
+	return unique
+}
+
+// Completions returns completions of the habit, oldest first.
+//
+// Habits stored before the history was recorded report
+// completions of their current streak.
+func (h *Habit) Completions() []Completion {
+	if len(h.History) == 0 {
+		return h.derivedHistory()
+	}
+	cx := make([]Completion, len(h.History))
+	copy(cx, h.History)
+	sort.SliceStable(cx, func(i, j int) bool { return cx[i].Date.Before(cx[j].Date) })
+	return cx
+}
+
+// rebuildStreak sets the date and the streak of the habit
+// to the last completion and the run of days ending on it,
+// and marks milestones reached on the way.
+func (h *Habit) rebuildStreak() {
+	days := h.Days()
+	if len(days) == 0 {
+		return
+	}
+	runs := streaks(days, h.Frequency.gap())
+	h.Date = days[len(days)-1]
+	h.Streak = runs[len(runs)-1]
+	h.markMilestones(days)
+}
+
+// DoneOn reports whether the habit was done on the given day.
+func (h *Habit) DoneOn(day time.Time) bool {
+	day = RoundDateToDay(day)
+	for _, d := range h.Days() {
+		if d.Equal(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unrecord removes the completion on the day of the given time
+// and rebuilds the streak. It returns an error if the habit wasn't
+// done on the day or if it's the only day the habit was done.
+func (h *Habit) Unrecord(t time.Time) error {
+	day := RoundDateToDay(t)
+	if !h.DoneOn(day) {
+		return fmt.Errorf("habit '%s' was not done on %s", h.Name, day.Format(dateLayout))
+	}
+	if len(h.Days()) == 1 {
+		return fmt.Errorf("can't remove the only day habit '%s' was done", h.Name)
+	}
+	var cx []Completion
+	for _, c := range h.Completions() {
+		if !RoundDateToDay(c.Date).Equal(day) {
+			cx = append(cx, c)
+		}
+	}
+	h.History = cx
+	h.rebuildStreak()
+	return nil
 }
 
 func (h *Habit) resetStreak() {
@@ -72,41 +224,112 @@ func (h *Habit) incStreak() {
 	h.Streak++
 }
 
-func (h *Habit) continueStreak() {
-	h.setDay(Now())
+func (h *Habit) continueStreak(t time.Time) {
+	h.setDay(t)
 	h.incStreak()
 }
 
 // Check verifies if the streak is broken.
 //
 // Returned value represents number of days since
-// the habit was logged last time.
+// the habit was logged last time. The message reports
+// the day of the challenge going on, if there is one.
 func (h *Habit) Check() (int, string) {
 	diff := h.checkStreak()
-	if diff == 0 || diff == 1 {
-		return diff, fmt.Sprintf("You're currently on a %d-day streak for '%s'. Stick to it!\n", h.Streak, h.Name)
+	challenge := h.challengeMessage(Now())
+	if diff <= h.Frequency.gap() {
+		return diff, message(msgStreak, h.Streak, h.messageData(diff), h.Name, h.Streak) + challenge
 	}
-	return diff, fmt.Sprintf("It's been %d days since you did '%s'. It's ok, life happens. Get back on that horse today!\n", diff, h.Name)
+	return diff, message(msgBroken, diff, h.messageData(diff), h.Name, diff) + challenge
 }
 
 func (h *Habit) checkStreak() int {
 	return DayDiff(h.Date, Now().UTC())
 }
 
+// EventKind describes what recording habit activity did.
+type EventKind string
+
+const (
+	// EventNone means the activity was already recorded on the day.
+	EventNone EventKind = ""
+	// EventContinued means the activity continued the streak.
+	EventContinued EventKind = "continued"
+	// EventMilestone means the activity continued the streak
+	// to the length of one of the habit's milestones.
+	EventMilestone EventKind = "milestone"
+	// EventRestarted means the streak was broken and a new one started.
+	EventRestarted EventKind = "restarted"
+	// EventBackfilled means the activity was recorded on
+	// a day before the habit was last done.
+	EventBackfilled EventKind = "backfilled"
+)
+
+// Event represents the outcome of recording habit activity.
+type Event struct {
+	Kind    EventKind
+	Habit   string
+	Streak  int
+	Message string
+}
+
 // Record records activity to the existing streak
 // or starts a new streak if the streak is broken.
 // It returns streak length and a corresponding message.
 func (h *Habit) Record() (int, string) {
-	diff := h.checkStreak()
-	if diff == 0 {
-		return h.Streak, ""
+	return h.RecordOn(Now())
+}
+
+// RecordOn records activity on the day of the given time.
+//
+// Days before the day the habit was last done are added
+// to the history and the streak is rebuilt from it.
+// It returns streak length and a corresponding message.
+func (h *Habit) RecordOn(t time.Time) (int, string) {
+	e := h.RecordEventOn(t)
+	return e.Streak, e.Message
+}
+
+// RecordEventOn records activity on the day of the given time,
+// like RecordOn, and returns the event describing the outcome.
+//
+// Reaching a milestone is marked on the day's completion.
+func (h *Habit) RecordEventOn(t time.Time) Event {
+	e := Event{Habit: h.Name}
+	day := RoundDateToDay(t)
+	if day.Before(RoundDateToDay(h.Date)) {
+		done := h.DoneOn(day)
+		if len(h.History) == 0 {
+			h.History = h.derivedHistory()
+		}
+		h.History = mergeCompletions(h.History, []Completion{{Date: day}})
+		h.rebuildStreak()
+		data := h.messageData(DayDiff(h.Date, Now()))
+		data.Date = day.Format(dateLayout)
+		e.Kind, e.Message = EventBackfilled, message(msgRecordedOn, 0, data, h.Name, data.Date)
+		if done {
+			e.Kind = EventNone
+		}
+		e.Streak = h.Streak
+		return e
 	}
-	if diff > 1 {
-		h.startNewStreak()
-		return h.Streak, fmt.Sprintf("You last did the habit '%s' %d days ago, so you're starting a new streak today. Good luck!\n", h.Name, diff)
+	diff := DayDiff(h.Date, day)
+	switch {
+	case diff == 0:
+		e.Kind = EventNone
+	case diff > h.Frequency.gap():
+		h.startNewStreak(t)
+		e.Kind, e.Message = EventRestarted, message(msgNewStreak, diff, h.messageData(diff), h.Name, diff)
+	case h.isMilestone(h.Streak + 1):
+		h.continueStreak(t)
+		h.History[len(h.History)-1].Milestone = h.Streak
+		e.Kind, e.Message = EventMilestone, message(msgMilestone, h.Streak, h.messageData(diff), h.Name, h.Streak)
+	default:
+		h.continueStreak(t)
+		e.Kind, e.Message = EventContinued, message(msgContinued, h.Streak, h.messageData(diff), h.Name, h.Streak)
 	}
-	h.continueStreak()
-	return h.Streak, fmt.Sprintf("Nice work: you've done the habit '%s' for %d days in a row now. Keep it up!\n", h.Name, h.Streak)
+	e.Streak = h.Streak
+	return e
 }
 
 // DayDiff takes two time obj and returns time delta in days.
@@ -140,45 +363,132 @@ func dataDir() string {
 // FileStore implements Store interface.
 type FileStore struct {
 	Path string
-	mu   sync.RWMutex
-	Data map[string]Habit
+	Key  *Key // Key holds the key the store is encrypted with, nil for plain JSON stores.
+	// Webhooks holds endpoints notified of habits logged, nil for none.
+	Webhooks *Webhooks
+	mu       sync.RWMutex
+	Data     map[string]Habit
+	global   []Achievement  // global holds achievements unlocked once for all habits.
+	events   []WebhookEvent // events holds events not yet delivered to webhooks.
+	stamp    storeStamp     // stamp identifies the version of the file last read or written.
+	lock     *os.File       // lock holds the lock file while the store is locked.
 }
 
 // NewFileStore takes a path and returns a file store.
 // It returns an error if it can't access the file.
+//
+// Encrypted stores are decrypted with the key given by KeyFromEnv
+// or, if there is none, with the passphrase PromptPassphrase asks for.
 func NewFileStore(path string) (*FileStore, error) {
-	hx := make(map[string]Habit)
 	store := FileStore{
 		Path: path,
-		Data: hx,
+		Data: make(map[string]Habit),
 	}
-	_, err := os.Stat(path)
-	if errors.Is(err, fs.ErrNotExist) {
-		return &store, nil
+	if err := store.load(); err != nil {
+		return nil, err
 	}
+	return &store, nil
+}
+
+// storeFile holds the content of the store's file.
+type storeFile struct {
+	Habits       map[string]Habit `json:"habits"`
+	Achievements []Achievement    `json:"achievements,omitempty"` // Achievements holds achievements unlocked once for all habits.
+}
 
-	data, err := os.ReadFile(path)
+// load reads habits from the store's file, decrypting them with
+// the store's key or, if it's not set, the key storeKey returns.
+//
+// Files written by older versions hold only the map of habits,
+// with global achievements stored in habits that unlocked them.
+// They're moved to the store, so they're not lost with the habit.
+func (f *FileStore) load() error {
+	stamp, err := statStore(f.Path)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if len(data) != 0 {
-		err = json.Unmarshal(data, &hx)
+	var content storeFile
+	if stamp.exists {
+		data, err := os.ReadFile(f.Path)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if IsEncrypted(data) {
+			if f.Key == nil {
+				f.Key, err = storeKey(f.Path)
+				if err != nil {
+					return err
+				}
+			}
+			data, err = Decrypt(data, f.Key)
+			if err != nil {
+				return fmt.Errorf("decrypting %s: %w", f.Path, err)
+			}
+		}
+		if len(data) != 0 {
+			if err := json.Unmarshal(data, &content); err != nil || content.Habits == nil {
+				content = storeFile{}
+				if err := json.Unmarshal(data, &content.Habits); err != nil {
+					return err
+				}
+			}
 		}
 	}
-	store.Data = hx
-	return &store, nil
+	if content.Habits == nil {
+		content.Habits = make(map[string]Habit)
+	}
+	for name, h := range content.Habits {
+		var own []Achievement
+		for _, a := range h.Achievements {
+			if r, ok := ruleByID(a.ID); ok && r.global() {
+				content.Achievements = mergeAchievements(content.Achievements, []Achievement{a})
+				continue
+			}
+			own = append(own, a)
+		}
+		if len(own) != len(h.Achievements) {
+			h.Achievements = own
+			content.Habits[name] = h
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Data, f.global, f.stamp = content.Habits, content.Achievements, stamp
+	return nil
 }
 
-// Save saves content of the store.
+// Save saves content of the store, encrypted
+// with the store's key if it's set.
+//
+// Events of habits logged since the last save are then
+// queued for delivery to the store's webhooks, so hooks hear
+// only of activity that was persisted, and Save doesn't wait
+// for them.
 func (f *FileStore) Save() error {
-	f.mu.RLock()
-	defer f.mu.RUnlock()
-	data, err := json.Marshal(f.Data)
+	if err := f.save(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	events := f.events
+	f.events = nil
+	f.mu.Unlock()
+	f.Webhooks.notify(events)
+	return nil
+}
+
+func (f *FileStore) save() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, err := json.Marshal(storeFile{Habits: f.Data, Achievements: f.global})
 	if err != nil {
 		return err
 	}
+	if f.Key != nil {
+		data, err = Encrypt(data, f.Key)
+		if err != nil {
+			return err
+		}
+	}
 	dir := filepath.Dir(f.Path)
 	_, err = os.Stat(dir)
 	if err != nil {
@@ -187,7 +497,11 @@ func (f *FileStore) Save() error {
 			return err
 		}
 	}
-	return os.WriteFile(f.Path, data, 0o600)
+	if err := os.WriteFile(f.Path, data, 0o600); err != nil {
+		return err
+	}
+	f.stamp, err = statStore(f.Path)
+	return err
 }
 
 // GetAll returns tracked habits sorted by name.
@@ -218,30 +532,192 @@ func (f *FileStore) Add(habit Habit) {
 	f.Data[habit.Name] = habit
 }
 
+// GlobalAchievements returns achievements unlocked once
+// for all habits, oldest first.
+func (f *FileStore) GlobalAchievements() []Achievement {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return append([]Achievement{}, f.global...)
+}
+
+// AddGlobalAchievements takes achievements unlocked once for all
+// habits and adds them to the store. Achievements already in the
+// store keep the earlier date.
+//
+// AddGlobalAchievements does not persist data in the store. After
+// calling AddGlobalAchievements(), call Save() to persist data.
+func (f *FileStore) AddGlobalAchievements(ax []Achievement) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.global = mergeAchievements(f.global, ax)
+}
+
+// Delete takes name and removes the habit from the store.
+// It returns false if the habit does not exist in the store.
+//
+// Delete does not persist data in the store. After
+// calling Delete(), call Save() to persist data.
+func (f *FileStore) Delete(habitName string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.Data[habitName]
+	delete(f.Data, habitName)
+	return ok
+}
+
+// Unlog takes a habit name and removes the completion of the
+// habit on the day of the given time, as Habit.Unrecord.
+//
+// Unlog does not persist data in the store. After
+// calling Unlog(), call Save() to persist data.
+func (f *FileStore) Unlog(habitName string, t time.Time) error {
+	h, ok := f.Get(habitName)
+	if !ok {
+		return fmt.Errorf("habit '%s' is not tracked", habitName)
+	}
+	if err := h.Unrecord(t); err != nil {
+		return err
+	}
+	f.Add(h)
+	return nil
+}
+
+// SetNote takes a habit name and sets the note of the habit's
+// completion on the day of the given time. It returns an error
+// if the habit was not done on the day.
+//
+// SetNote does not persist data in the store. After
+// calling SetNote(), call Save() to persist data.
+func (f *FileStore) SetNote(habitName string, t time.Time, note string) error {
+	h, ok := f.Get(habitName)
+	if !ok {
+		return fmt.Errorf("habit '%s' is not tracked", habitName)
+	}
+	day := RoundDateToDay(t)
+	if !h.DoneOn(day) {
+		return fmt.Errorf("habit '%s' was not done on %s", habitName, day.Format(dateLayout))
+	}
+	h.History = h.Completions()
+	for i, c := range h.History {
+		if RoundDateToDay(c.Date).Equal(day) {
+			h.History[i].Note = note
+		}
+	}
+	f.Add(h)
+	return nil
+}
+
+// ImportMode defines how imported habits are combined
+// with habits already present in the store.
+type ImportMode int
+
+const (
+	// ImportMerge adds imported completions to existing habits.
+	ImportMerge ImportMode = iota
+	// ImportReplace replaces all habits in the store with imported habits.
+	ImportReplace
+)
+
+// Import takes habits and adds them to the store using the given mode.
+//
+// Import does not persist data in the store. After
+// calling Import(), call Save() to persist data.
+func (f *FileStore) Import(hx []Habit, mode ImportMode) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if mode == ImportReplace {
+		f.Data = make(map[string]Habit)
+	}
+	for _, h := range hx {
+		existing, ok := f.Data[h.Name]
+		if ok {
+			existing.History = mergeCompletions(existing.Completions(), h.Completions())
+			if existing.Frequency == nil {
+				existing.Frequency = h.Frequency
+			}
+			existing.rebuildStreak()
+			h = existing
+		}
+		f.Data[h.Name] = h
+	}
+}
+
+// mergeCompletions returns completions from both slices, one per day,
+// oldest first. If both slices hold the same day, empty fields
+// of the completion from a are filled from b.
+func mergeCompletions(a, b []Completion) []Completion {
+	days := make(map[time.Time]Completion)
+	for _, c := range append(append([]Completion{}, a...), b...) {
+		day := RoundDateToDay(c.Date)
+		existing, ok := days[day]
+		if !ok {
+			c.Date = day
+			days[day] = c
+			continue
+		}
+		if existing.Amount == 0 {
+			existing.Amount = c.Amount
+		}
+		if existing.Note == "" {
+			existing.Note = c.Note
+		}
+		if existing.Milestone == 0 {
+			existing.Milestone = c.Milestone
+		}
+		if existing.LoggedAt == nil {
+			existing.LoggedAt = c.LoggedAt
+		}
+		days[day] = existing
+	}
+	cx := maps.Values(days)
+	sort.Slice(cx, func(i, j int) bool { return cx[i].Date.Before(cx[j].Date) })
+	return cx
+}
+
 // Log takes a string representing habit's name and logs the habit.
 // If habit with given name does not exist, Log creates it and
 // starts tracking.
 func (f *FileStore) Log(habitName string) (string, error) {
+	return f.LogOn(habitName, Now())
+}
+
+// LogOn takes a string representing habit's name and logs the habit
+// on the day of the given time. If habit with given name does not
+// exist, LogOn creates it and starts tracking. It returns an error
+// if the day is after today.
+func (f *FileStore) LogOn(habitName string, t time.Time) (string, error) {
+	if day := RoundDateToDay(t); day.After(RoundDateToDay(Now())) {
+		return "", fmt.Errorf("can't log habit '%s' on %s, after today", habitName, day.Format(dateLayout))
+	}
 	h, ok := f.Get(habitName)
+	var msg string
 	if !ok {
-		h, err := New(habitName)
+		var err error
+		h, err = New(habitName)
 		if err != nil {
 			return "", err
 		}
-		msg := h.Start()
-		f.Add(h)
-		return msg, nil
+		h.Date = RoundDateToDay(t)
+		msg = h.start(t)
+		f.queueEvents(createdEvents(h, t, msg))
+	} else {
+		streak := h.Streak
+		e := h.RecordEventOn(t)
+		msg = e.Message
+		f.queueEvents(recordedEvents(e, streak, t))
+	}
+	if c, ok := h.settleChallenge(t); ok {
+		msg += h.challengeResultMessage(c)
 	}
-	_, msg := h.Record()
 	f.Add(h)
-	return msg, nil
+	return msg + f.unlockAchievements(habitName, t), nil
 }
 
 // Check takes a store and reports about all tracked habits.
 func Check(s Store) string {
 	habits := s.GetAll()
 	if len(habits) == 0 {
-		return "You are not tracking any habit yet.\n"
+		return message(msgNotTracking, 0, MessageData{})
 	}
 	var sb strings.Builder
 	for _, habit := range habits {
@@ -253,7 +729,36 @@ func Check(s Store) string {
 
 // Record takes store and habitName and records habit activity.
 // It creates a new habit if habit with provided name does not exist.
+//
+// If the name is not tracked but is similar to the name of a tracked
+// habit, Record returns SimilarHabitError instead of creating a new
+// habit. Use Create to start tracking such habit.
 func Record(s Store, habitName string) (string, error) {
+	hx := s.GetAll()
+	for _, h := range hx {
+		if h.Name == habitName {
+			return record(s, habitName)
+		}
+	}
+	if similar, ok := SimilarHabit(hx, habitName); ok {
+		return "", &SimilarHabitError{Name: habitName, Similar: similar.Name}
+	}
+	return record(s, habitName)
+}
+
+// Create takes store and habitName and starts tracking a new habit,
+// even if its name is similar to the name of a tracked habit.
+// It returns an error if the habit is already tracked.
+func Create(s Store, habitName string) (string, error) {
+	for _, h := range s.GetAll() {
+		if h.Name == habitName {
+			return "", fmt.Errorf("habit '%s' is already tracked", habitName)
+		}
+	}
+	return record(s, habitName)
+}
+
+func record(s Store, habitName string) (string, error) {
 	msg, err := s.Log(habitName)
 	if err != nil {
 		return "", err
@@ -266,23 +771,172 @@ func Record(s Store, habitName string) (string, error) {
 
 func runCLI(wr, ew io.Writer) int {
 	fset := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
-	fset.Parse(os.Args[1:])
+	fset.SetOutput(ew)
+	storeFlag := fset.String("store", "", "path of a file store, or URL of a habit server, to use instead of the default store")
+	profile := fset.String("profile", "", "name of the profile whose store to use")
+	create := fset.Bool("create", false, "start tracking a new habit even if its name is similar to a tracked habit")
+	lang := fset.String("lang", LanguageFromEnv(), "language of messages: "+strings.Join(Languages(), ", "))
+	if err := fset.Parse(os.Args[1:]); err != nil {
+		return 1
+	}
+	if err := SetLanguage(*lang); err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	if dir := templateDir(); dir != "" {
+		if err := LoadTemplates(dir); err != nil {
+			fmt.Fprint(ew, err)
+			return 1
+		}
+	}
+	if path := achievementsPath(); path != "" {
+		if err := LoadRules(path); err != nil {
+			fmt.Fprint(ew, err)
+			return 1
+		}
+	}
 	args := fset.Args()
 
-	// Default file storage is created.
-	store, err := NewFileStore(dataDir() + "/.habits.json")
+	// Status lines and shell completion run habit without the
+	// user waiting for it, so they never prompt for the passphrase
+	// and fail at once if it's not in the environment.
+	if len(args) == 0 || args[0] != "status" && args[0] != "completion" {
+		PromptPassphrase = terminalPassphrase(ew, "Passphrase: ")
+	}
+	location, err := storeLocation(*storeFlag, *profile)
 	if err != nil {
 		fmt.Fprint(ew, err)
 		return 1
 	}
+	if len(args) > 0 && args[0] == "profiles" {
+		return runProfiles(location, args[1:], wr, ew)
+	}
+
+	// Status is printed on every prompt render, so the store
+	// is opened only when the cached status is not valid.
+	if len(args) > 0 && args[0] == "status" {
+		var storePath string
+		if !isURL(location) {
+			storePath = location
+		}
+		return runStatus(func() (Store, error) { return openStore(location) }, storePath, args[1:], wr, ew)
+	}
+
+	store, err := openStore(location)
+	if err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	if fstore, ok := store.(*FileStore); ok && webhooksPath() != "" {
+		fstore.Webhooks, err = LoadWebhooks(webhooksPath())
+		if err != nil {
+			fmt.Fprint(ew, err)
+			return 1
+		}
+		if fstore.Webhooks != nil {
+			fstore.Webhooks.ErrorLog = ew
+			// Deferred before unlocking the store, so it runs after it.
+			defer fstore.Webhooks.Wait()
+		}
+	}
+	// The server and the UI run long, so they lock the store
+	// only while changing it.
+	if fstore, ok := store.(*FileStore); ok && (len(args) == 0 || args[0] != "serve" && args[0] != "tui") {
+		if err := fstore.Lock(); err != nil {
+			fmt.Fprint(ew, err)
+			return 1
+		}
+		defer fstore.Unlock()
+	}
 
 	// No args, checking habits
 	if len(args) == 0 {
+		if hs, ok := store.(*HTTPStore); ok {
+			if err := hs.Refresh(); err != nil {
+				fmt.Fprint(ew, err)
+				return 1
+			}
+		}
+		if fstore, ok := store.(*FileStore); ok && fstore.SettleChallenges(Now()) {
+			if err := fstore.Save(); err != nil {
+				fmt.Fprint(ew, err)
+				return 1
+			}
+		}
 		fmt.Fprint(wr, Check(store))
 		return 0
 	}
 
-	msg, err := Record(store, args[0])
+	switch args[0] {
+	case "calendar":
+		return runCalendar(store, args[1:], wr, ew)
+	case "stats":
+		return runStats(store, args[1:], wr, ew)
+	case "due":
+		return runDue(store, args[1:], wr, ew)
+	case "milestones":
+		return runMilestones(store, args[1:], wr, ew)
+	case "achievements":
+		return runAchievements(store, args[1:], wr, ew)
+	case "challenges":
+		return runChallenges(store, args[1:], wr, ew)
+	case "tui":
+		return runTUI(store, args[1:], wr, ew)
+	case "export":
+		return runExport(store, args[1:], wr, ew)
+	case "report":
+		return runReport(store, args[1:], wr, ew)
+	case "metrics":
+		return runMetrics(store, args[1:], wr, ew)
+	case "digest":
+		return runDigest(store, args[1:], wr, ew)
+	case "completion":
+		return runCompletion(store, args[1:], wr, ew)
+	case "import", "merge", "serve", "encrypt", "decrypt", "challenge":
+		fstore, ok := store.(*FileStore)
+		if !ok {
+			fmt.Fprintf(ew, "habit %s works only with a local file store", args[0])
+			return 1
+		}
+		switch args[0] {
+		case "import":
+			return runImport(fstore, args[1:], wr, ew)
+		case "merge":
+			return runMerge(fstore, args[1:], wr, ew)
+		case "encrypt":
+			return runEncrypt(fstore, args[1:], wr, ew)
+		case "decrypt":
+			return runDecrypt(fstore, args[1:], wr, ew)
+		case "challenge":
+			return runChallenge(fstore, args[1:], wr, ew)
+		}
+		return runServe(fstore, args[1:], wr, ew)
+	}
+
+	// Habits named like a subcommand are recorded with habit log.
+	name := args[0]
+	if args[0] == "new" || args[0] == "log" {
+		if len(args) != 2 {
+			fmt.Fprint(ew, fmt.Errorf("usage: habit %s <name>", args[0]))
+			return 1
+		}
+		name = args[1]
+	}
+	var msg string
+	switch {
+	case args[0] == "new":
+		msg, err = Create(store, name)
+	case *create:
+		msg, err = record(store, name)
+	default:
+		msg, err = Record(store, name)
+	}
+	var similar *SimilarHabitError
+	if errors.As(err, &similar) && isTerminal(os.Stdin) {
+		if confirm(os.Stdin, wr, fmt.Sprintf("Did you mean '%s'? [Y/n] ", similar.Similar)) {
+			msg, err = Record(store, similar.Similar)
+		}
+	}
 	if err != nil {
 		fmt.Fprint(ew, err)
 		return 1
@@ -291,6 +945,29 @@ func runCLI(wr, ew io.Writer) int {
 	return 0
 }
 
+// openStore takes a store location and returns a store talking
+// to the habit server, if the location is a URL, or the file
+// store at the location otherwise.
+func openStore(location string) (Store, error) {
+	if isURL(location) {
+		return NewHTTPStore(location, queuePath(location))
+	}
+	return NewFileStore(location)
+}
+
+// queuePath returns path to the file with logs queued for
+// the habit server at the URL. Every server has its own queue,
+// so logs are never replayed on a server they weren't made for.
+func queuePath(serverURL string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSuffix(serverURL, "/")))
+	return fmt.Sprintf("%s/.habits-queue-%x.json", dataDir(), sum[:8])
+}
+
+// fileStorePath returns path to the default file store.
+func fileStorePath() string {
+	return dataDir() + "/.habits.json"
+}
+
 func Main() int {
 	return runCLI(os.Stdout, os.Stderr)
 }
@@ -103,9 +103,10 @@ func TestStart_StartsTrackingHabit(t *testing.T) {
 	}
 
 	wantHabit := habit.Habit{
-		Name:   habitName,
-		Date:   date,
-		Streak: 1,
+		Name:    habitName,
+		Date:    date,
+		Streak:  1,
+		History: []habit.Completion{loggedAt(habit.Completion{Date: date}, testTime)},
 	}
 
 	if !cmp.Equal(wantHabit, gotHabit) {
@@ -313,10 +314,41 @@ func TestLog_StartsNewHabitStreakAfterBrokenStreak(t *testing.T) {
 	}
 }
 
+func TestRecordOn_BackfillsDayBeforeLastActivity(t *testing.T) {
+	t.Parallel()
+
+	h := habit.Habit{
+		Name:    "jog",
+		Date:    time.Date(2022, 9, 5, 0, 0, 0, 0, time.UTC),
+		Streak:  1,
+		History: []habit.Completion{{Date: time.Date(2022, 9, 5, 0, 0, 0, 0, time.UTC)}},
+	}
+
+	got, msg := h.RecordOn(time.Date(2022, 9, 4, 18, 0, 0, 0, time.UTC))
+	want := 2
+	if want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+	wantMsg := "Recorded the habit 'jog' on 2022-09-04.\n"
+	if wantMsg != msg {
+		t.Error(cmp.Diff(wantMsg, msg))
+	}
+	wantDate := time.Date(2022, 9, 5, 0, 0, 0, 0, time.UTC)
+	if !cmp.Equal(wantDate, h.Date) {
+		t.Error(cmp.Diff(wantDate, h.Date))
+	}
+}
+
 func testPath(t *testing.T) string {
 	return t.TempDir() + "/.habits.json"
 }
 
+// loggedAt returns the completion logged at the given time.
+func loggedAt(c habit.Completion, t time.Time) habit.Completion {
+	c.LoggedAt = &t
+	return c
+}
+
 func TestNewFileStore_CreatesNewEmptyStore(t *testing.T) {
 	testTime, err := time.Parse(time.RFC3339, "2022-10-01T00:00:00Z")
 	if err != nil {
@@ -424,6 +456,10 @@ func TestLog_AddsHabitToFileStore(t *testing.T) {
 		Name:   "jog",
 		Date:   time.Date(2022, 10, 0o2, 0o0, 0o0, 0o0, 0o0, time.UTC),
 		Streak: 2,
+		History: []habit.Completion{
+			loggedAt(habit.Completion{Date: time.Date(2022, 10, 0o1, 0o0, 0o0, 0o0, 0o0, time.UTC)}, time.Date(2022, 10, 0o1, 0o0, 0o0, 0o0, 0o0, time.UTC)),
+			loggedAt(habit.Completion{Date: time.Date(2022, 10, 0o2, 0o0, 0o0, 0o0, 0o0, time.UTC)}, testTime),
+		},
 	}
 
 	if !cmp.Equal(want, got) {
@@ -454,9 +490,10 @@ func TestLog_SavesHabitToEmptyFileStore(t *testing.T) {
 	}
 
 	want := habit.Habit{
-		Name:   "jog",
-		Date:   time.Date(2022, 10, 0o1, 0o0, 0o0, 0o0, 0o0, time.UTC),
-		Streak: 1,
+		Name:    "jog",
+		Date:    time.Date(2022, 10, 0o1, 0o0, 0o0, 0o0, 0o0, time.UTC),
+		Streak:  1,
+		History: []habit.Completion{loggedAt(habit.Completion{Date: time.Date(2022, 10, 0o1, 0o0, 0o0, 0o0, 0o0, time.UTC)}, testTime)},
 	}
 
 	got, ok := store.Data["jog"]
@@ -494,9 +531,10 @@ func TestStoreLog_SavesHabitToFileStore(t *testing.T) {
 	hx := store.GetAll()
 	got := hx[0]
 	want := habit.Habit{
-		Name:   "run",
-		Date:   time.Date(2022, 9, 1, 0o0, 0o0, 0o0, 0o0, time.UTC),
-		Streak: 1,
+		Name:    "run",
+		Date:    time.Date(2022, 9, 1, 0o0, 0o0, 0o0, 0o0, time.UTC),
+		Streak:  1,
+		History: []habit.Completion{loggedAt(habit.Completion{Date: time.Date(2022, 9, 1, 0o0, 0o0, 0o0, 0o0, time.UTC)}, testTime)},
 	}
 	if !cmp.Equal(want, got) {
 		t.Errorf(cmp.Diff(want, got))
@@ -703,6 +741,65 @@ func TestLog_LogsMultipleHabitsWithTwoDaysStreak(t *testing.T) {
 	}
 }
 
+func TestUnrecord_RemovesDayAndRebuildsStreak(t *testing.T) {
+	t.Parallel()
+
+	h := habit.Habit{Name: "jog", Date: day(3).Date, Streak: 3, History: []habit.Completion{day(1), day(2), day(3)}}
+	if err := h.Unrecord(day(3).Date.Add(5 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	want := habit.Habit{Name: "jog", Date: day(2).Date, Streak: 2, History: []habit.Completion{day(1), day(2)}}
+	if !cmp.Equal(want, h) {
+		t.Error(cmp.Diff(want, h))
+	}
+	if err := h.Unrecord(day(3).Date); err == nil {
+		t.Error("want error on removing day not done")
+	}
+	h.Unrecord(day(1).Date)
+	if err := h.Unrecord(day(2).Date); err == nil {
+		t.Error("want error on removing the only day")
+	}
+}
+
+func TestRecord_KeepsStreakOfHabitWithFrequencyOverRestDays(t *testing.T) {
+	// Done Monday to Friday, 5 times a week.
+	h := habit.Habit{Name: "work out", Date: day(7).Date, Streak: 5, History: days(3, 7), Frequency: &habit.Frequency{Times: 5, Days: 7}}
+	setNow(t, day(9).Date)
+	if _, got := h.Check(); !strings.Contains(got, "5-day streak") {
+		t.Errorf("want streak kept over the weekend, got %q", got)
+	}
+	setNow(t, day(10).Date)
+	if streak, _ := h.Record(); streak != 6 {
+		t.Errorf("want streak continued on Monday, got %d", streak)
+	}
+
+	setNow(t, day(14).Date)
+	if _, got := h.Check(); !strings.Contains(got, "4 days") {
+		t.Errorf("want streak broken after 4 days of rest, got %q", got)
+	}
+	if streak, _ := h.Record(); streak != 1 {
+		t.Errorf("want new streak, got %d", streak)
+	}
+}
+
+func TestUnrecord_RebuildsStreakOfHabitWithFrequencyOverRestDays(t *testing.T) {
+	t.Parallel()
+
+	h := habit.Habit{Name: "gym", Date: day(8).Date, Streak: 4, History: []habit.Completion{day(1), day(3), day(5), day(8)}, Frequency: &habit.Frequency{Times: 3, Days: 7}}
+	if err := h.Unrecord(day(3).Date); err != nil {
+		t.Fatal(err)
+	}
+	if h.Streak != 3 {
+		t.Errorf("want streak kept over 3 days of rest, got %d", h.Streak)
+	}
+	if err := h.Unrecord(day(5).Date); err != nil {
+		t.Fatal(err)
+	}
+	if h.Streak != 1 {
+		t.Errorf("want streak broken by 6 days of rest, got %d", h.Streak)
+	}
+}
+
 func TestMain(m *testing.M) {
 	os.Exit(testscript.RunMain(m, map[string]func() int{
 		"habit": habit.Main,
@@ -747,6 +844,9 @@ func cmdDate(ts *testscript.TestScript, neg bool, args []string) {
 
 	newDate := habit.RoundDateToDay(h.Date.AddDate(0, 0, dayShift))
 	h.Date = newDate
+	for i, c := range h.History {
+		h.History[i].Date = habit.RoundDateToDay(c.Date.AddDate(0, 0, dayShift))
+	}
 	fstore.Add(h)
 	err = fstore.Save()
 	if err != nil {
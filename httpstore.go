@@ -0,0 +1,262 @@
+package habit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPStore implements Store interface on top of
+// a habit server started with `habit serve`.
+//
+// Logs made while the server is not reachable are queued
+// and replayed, with their original time, once the server
+// is reachable again. Once a request fails after all retries,
+// the store doesn't contact the server again, so commands run
+// offline wait for a single timeout only.
+type HTTPStore struct {
+	URL        string
+	HTTPClient *http.Client
+	Retries    int           // Retries holds number of retries of failed requests.
+	Backoff    time.Duration // Backoff holds delay before the first retry, doubled on every retry.
+	QueuePath  string        // QueuePath holds path to the file with queued logs.
+
+	mu          sync.Mutex
+	habits      []Habit
+	queue       []QueuedLog
+	unreachable atomic.Bool
+}
+
+// QueuedLog represents habit activity logged while
+// the habit server was not reachable.
+type QueuedLog struct {
+	Name string    `json:"name"`
+	Date time.Time `json:"date"`
+}
+
+// errUnreachable indicates the server did not respond,
+// or responded with a server error, after all retries.
+var errUnreachable = errors.New("habit server is not reachable")
+
+// NewHTTPStore takes URL of a habit server and path to the file
+// with queued logs and returns a store. It returns an error if
+// the URL is invalid or the queue can't be read.
+func NewHTTPStore(serverURL, queuePath string) (*HTTPStore, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid habit server URL: %s", serverURL)
+	}
+	s := HTTPStore{
+		URL:        strings.TrimSuffix(serverURL, "/"),
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		Retries:    2,
+		Backoff:    500 * time.Millisecond,
+		QueuePath:  queuePath,
+	}
+	data, err := os.ReadFile(queuePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if len(data) != 0 {
+		if err := json.Unmarshal(data, &s.queue); err != nil {
+			return nil, fmt.Errorf("reading queued logs: %w", err)
+		}
+	}
+	return &s, nil
+}
+
+// Refresh fetches habits from the server.
+func (s *HTTPStore) Refresh() error {
+	var hx []Habit
+	if err := s.do(http.MethodGet, "/habits", nil, &hx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.habits = hx
+	return nil
+}
+
+// GetAll returns habits sorted by name, as last fetched
+// from the server. If the server is not reachable it returns
+// habits fetched before.
+func (s *HTTPStore) GetAll() []Habit {
+	s.Refresh()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hx := make([]Habit, len(s.habits))
+	copy(hx, s.habits)
+	return hx
+}
+
+// GlobalAchievements returns achievements unlocked once for all
+// habits, fetched from the server. It returns none if the server
+// is not reachable, or keeps them in habits, like older versions.
+func (s *HTTPStore) GlobalAchievements() []Achievement {
+	var ax []Achievement
+	if err := s.do(http.MethodGet, "/achievements", nil, &ax); err != nil {
+		return nil
+	}
+	return ax
+}
+
+// Log takes a string representing habit's name and logs the habit
+// on the server. If the server is not reachable, the log is queued.
+//
+// Queued logs are sent before the new one, and persisted
+// by calling Save().
+func (s *HTTPStore) Log(habitName string) (string, error) {
+	if habitName == "" {
+		return "", errors.New("name cannot be empty")
+	}
+	if err := s.Flush(); err != nil && !errors.Is(err, errUnreachable) {
+		return "", err
+	}
+	entry := QueuedLog{Name: habitName, Date: Now()}
+	s.mu.Lock()
+	queued := len(s.queue) > 0
+	s.mu.Unlock()
+	if !queued {
+		msg, err := s.send(entry)
+		if !errors.Is(err, errUnreachable) {
+			return msg, err
+		}
+	}
+	s.mu.Lock()
+	s.queue = append(s.queue, entry)
+	s.mu.Unlock()
+	return fmt.Sprintf("Can't reach %s right now, '%s' will be logged once it's back.\n", s.URL, habitName), nil
+}
+
+// Flush replays queued logs on the server, oldest first.
+// Logs the server rejects are dropped; it returns an error
+// reporting them. Logs not sent remain in the queue.
+func (s *HTTPStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for len(s.queue) > 0 {
+		_, err := s.send(s.queue[0])
+		if errors.Is(err, errUnreachable) {
+			errs = append(errs, err)
+			break
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("replaying log of '%s' on %s: %w", s.queue[0].Name, s.queue[0].Date.Format(dateLayout), err))
+		}
+		s.queue = s.queue[1:]
+	}
+	return errors.Join(errs...)
+}
+
+// Queued returns logs waiting to be sent to the server.
+func (s *HTTPStore) Queued() []QueuedLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]QueuedLog{}, s.queue...)
+}
+
+// Save persists queued logs. If there are no queued
+// logs it removes the queue file.
+func (s *HTTPStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		err := os.Remove(s.QueuePath)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	data, err := json.Marshal(s.queue)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.QueuePath), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.QueuePath, data, 0o600)
+}
+
+func (s *HTTPStore) send(entry QueuedLog) (string, error) {
+	var resp logResponse
+	err := s.do(http.MethodPost, "/habits/"+url.PathEscape(entry.Name)+"/log", logRequest{Date: entry.Date}, &resp)
+	if err != nil {
+		return "", err
+	}
+	return resp.Message, nil
+}
+
+// do sends a request with JSON body and decodes the JSON response
+// into v. It retries requests failed because of network or server
+// errors, waiting longer before every retry.
+func (s *HTTPStore) do(method, path string, body, v any) error {
+	if s.unreachable.Load() {
+		return errUnreachable
+	}
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+	backoff := s.Backoff
+	var lastErr error
+	for attempt := 0; attempt <= s.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		req, err := http.NewRequest(method, s.URL+path, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := s.HTTPClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("%s %s: %s", method, path, resp.Status)
+			continue
+		}
+		if resp.StatusCode >= 400 {
+			var e errorResponse
+			if json.Unmarshal(data, &e) == nil && e.Error != "" {
+				return errors.New(e.Error)
+			}
+			return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+		}
+		if v == nil {
+			return nil
+		}
+		return json.Unmarshal(data, v)
+	}
+	s.unreachable.Store(true)
+	return fmt.Errorf("%w: %v", errUnreachable, lastErr)
+}
@@ -0,0 +1,161 @@
+package habit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/habit"
+)
+
+func newTestHTTPStore(t *testing.T, serverURL string) *habit.HTTPStore {
+	t.Helper()
+	store, err := habit.NewHTTPStore(serverURL, filepath.Join(t.TempDir(), "queue.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Backoff = time.Millisecond
+	return store
+}
+
+func TestHTTPStore_LogsHabitOnServer(t *testing.T) {
+	setNow(t, time.Date(2022, 10, 4, 8, 0, 0, 0, time.UTC))
+	ts, _ := newTestServer(t)
+	store := newTestHTTPStore(t, ts.URL)
+
+	got, err := habit.Record(store, "jog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Good luck with your new habit 'jog'. Don't forget to do it tomorrow.\n"
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+
+	gotHabits := store.GetAll()
+	wantHabits := []habit.Habit{
+		{Name: "jog", Date: day(4).Date, Streak: 1, History: []habit.Completion{loggedAt(day(4), time.Date(2022, 10, 4, 8, 0, 0, 0, time.UTC))}},
+	}
+	if !cmp.Equal(wantHabits, gotHabits) {
+		t.Error(cmp.Diff(wantHabits, gotHabits))
+	}
+}
+
+func TestHTTPStore_ReplaysQueuedLogsWithOriginalTime(t *testing.T) {
+	ts, serverStore := newTestServer(t)
+	queuePath := filepath.Join(t.TempDir(), "queue.json")
+
+	offline, err := habit.NewHTTPStore("http://127.0.0.1:1", queuePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offline.Retries = 0
+	setNow(t, time.Date(2022, 10, 3, 8, 0, 0, 0, time.UTC))
+	msg, err := habit.Record(offline, "jog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(msg, "will be logged once it's back") {
+		t.Errorf("want message about queued log, got %q", msg)
+	}
+
+	setNow(t, time.Date(2022, 10, 4, 8, 0, 0, 0, time.UTC))
+	online, err := habit.NewHTTPStore(ts.URL, queuePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(online.Queued()) != 1 {
+		t.Fatalf("want 1 queued log, got %d", len(online.Queued()))
+	}
+	got, err := habit.Record(online, "jog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Nice work: you've done the habit 'jog' for 2 days in a row now. Keep it up!\n"
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+	if len(online.Queued()) != 0 {
+		t.Errorf("want empty queue, got %v", online.Queued())
+	}
+
+	h, ok := serverStore.Get("jog")
+	if !ok {
+		t.Fatal("habit 'jog' not logged on server")
+	}
+	wantHistory := []habit.Completion{
+		loggedAt(day(3), time.Date(2022, 10, 3, 8, 0, 0, 0, time.UTC)),
+		loggedAt(day(4), time.Date(2022, 10, 4, 8, 0, 0, 0, time.UTC)),
+	}
+	if !cmp.Equal(wantHistory, h.History) {
+		t.Error(cmp.Diff(wantHistory, h.History))
+	}
+}
+
+func TestHTTPStore_RetriesOnServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+	store := newTestHTTPStore(t, ts.URL)
+
+	if err := store.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("want 3 requests, got %d", got)
+	}
+}
+
+func TestHTTPStore_StopsContactingUnreachableServer(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	store := newTestHTTPStore(t, ts.URL)
+	store.Retries = 1
+
+	for _, name := range []string{"jog", "read"} {
+		if _, err := store.Log(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.GetAll()
+	if got := calls.Load(); got != 2 {
+		t.Errorf("want 2 requests, got %d", got)
+	}
+	if got := len(store.Queued()); got != 2 {
+		t.Errorf("want 2 queued logs, got %d", got)
+	}
+}
+
+func TestHTTPStore_ReturnsErrorsReportedByServer(t *testing.T) {
+	ts, _ := newTestServer(t)
+	store := newTestHTTPStore(t, ts.URL)
+
+	_, err := store.Log("")
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+}
+
+func TestNewHTTPStore_ErrorsOnInvalidURL(t *testing.T) {
+	t.Parallel()
+
+	_, err := habit.NewHTTPStore("localhost:8080", filepath.Join(t.TempDir(), "queue.json"))
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+}
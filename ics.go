@@ -0,0 +1,88 @@
+package habit
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ExportICS takes habits and writes their completions as
+// an iCalendar (RFC 5545) with an all-day event per completion.
+//
+// Events have stable UIDs derived from the habit name and
+// the day, so importing the calendar again updates events
+// instead of duplicating them.
+func ExportICS(w io.Writer, hx []Habit) error {
+	iw := icsWriter{w: w}
+	iw.line("BEGIN:VCALENDAR")
+	iw.line("VERSION:2.0")
+	iw.line("PRODID:-//qba73//habit//EN")
+	iw.line("CALSCALE:GREGORIAN")
+	for _, h := range hx {
+		for _, c := range h.Completions() {
+			day := RoundDateToDay(c.Date)
+			iw.line("BEGIN:VEVENT")
+			iw.line("UID:" + icsUID(h.Name, day.Format(dateLayout)))
+			iw.line("DTSTAMP:" + day.Format("20060102T150405Z"))
+			iw.line("DTSTART;VALUE=DATE:" + day.Format("20060102"))
+			iw.line("DTEND;VALUE=DATE:" + day.AddDate(0, 0, 1).Format("20060102"))
+			iw.line("SUMMARY:" + icsEscape(h.Name))
+			if desc := icsDescription(c); desc != "" {
+				iw.line("DESCRIPTION:" + icsEscape(desc))
+			}
+			iw.line("TRANSP:TRANSPARENT")
+			iw.line("END:VEVENT")
+		}
+	}
+	iw.line("END:VCALENDAR")
+	return iw.err
+}
+
+func icsUID(name, day string) string {
+	return fmt.Sprintf("%x@habit", sha1.Sum([]byte(name+"\x00"+day)))
+}
+
+func icsDescription(c Completion) string {
+	var parts []string
+	if c.Amount != 0 {
+		parts = append(parts, "Amount: "+strconv.FormatFloat(c.Amount, 'f', -1, 64))
+	}
+	if c.Note != "" {
+		parts = append(parts, c.Note)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// icsEscape escapes text values as required by RFC 5545, section 3.3.11.
+func icsEscape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// icsWriter writes content lines terminated with CRLF and folded
+// to 75 octets as required by RFC 5545, section 3.1.
+type icsWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (iw *icsWriter) line(s string) {
+	if iw.err != nil {
+		return
+	}
+	var sb strings.Builder
+	width := 0
+	for _, r := range s {
+		n := len(string(r))
+		if width+n > 75 {
+			sb.WriteString("\r\n ")
+			width = 1
+		}
+		sb.WriteRune(r)
+		width += n
+	}
+	sb.WriteString("\r\n")
+	_, iw.err = io.WriteString(iw.w, sb.String())
+}
@@ -0,0 +1,86 @@
+package habit_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/qba73/habit"
+)
+
+func TestExportICS_WritesAllDayEventPerCompletion(t *testing.T) {
+	t.Parallel()
+
+	hx := []habit.Habit{
+		{Name: "jog", Date: day(4).Date, Streak: 2, History: []habit.Completion{
+			{Date: day(3).Date, Amount: 5.5, Note: "park, sunny"},
+			day(4),
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := habit.ExportICS(&buf, hx); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	if !strings.HasPrefix(got, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n") {
+		t.Errorf("want calendar header, got %q", got)
+	}
+	if !strings.HasSuffix(got, "END:VCALENDAR\r\n") {
+		t.Errorf("want calendar footer, got %q", got)
+	}
+	if n := strings.Count(got, "BEGIN:VEVENT\r\n"); n != 2 {
+		t.Errorf("want 2 events, got %d", n)
+	}
+	for _, want := range []string{
+		"DTSTART;VALUE=DATE:20221003\r\nDTEND;VALUE=DATE:20221004\r\n",
+		"DTSTART;VALUE=DATE:20221004\r\nDTEND;VALUE=DATE:20221005\r\n",
+		"SUMMARY:jog\r\n",
+		`DESCRIPTION:Amount: 5.5\npark\, sunny` + "\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want calendar containing %q, got %q", want, got)
+		}
+	}
+}
+
+func TestExportICS_UsesStableEventUIDs(t *testing.T) {
+	t.Parallel()
+
+	h := habit.Habit{Name: "jog", Date: day(4).Date, Streak: 1, History: []habit.Completion{day(4)}}
+
+	var first, second bytes.Buffer
+	if err := habit.ExportICS(&first, []habit.Habit{h}); err != nil {
+		t.Fatal(err)
+	}
+	h.History = append([]habit.Completion{day(1)}, h.History...)
+	if err := habit.ExportICS(&second, []habit.Habit{h}); err != nil {
+		t.Fatal(err)
+	}
+
+	uid := func(s string) string {
+		i := strings.Index(s, "DTSTART;VALUE=DATE:20221004")
+		j := strings.LastIndex(s[:i], "UID:")
+		return s[j:i]
+	}
+	if uid(first.String()) != uid(second.String()) {
+		t.Errorf("want stable UID, got %q and %q", uid(first.String()), uid(second.String()))
+	}
+}
+
+func TestExportICS_FoldsLongLines(t *testing.T) {
+	t.Parallel()
+
+	h := habit.Habit{Name: strings.Repeat("ćwiczenia ", 20), Date: day(4).Date, Streak: 1}
+
+	var buf bytes.Buffer
+	if err := habit.ExportICS(&buf, []habit.Habit{h}); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("want line of at most 75 octets, got %d: %q", len(line), line)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package habit
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// pluralForm represents a CLDR plural category of a number.
+type pluralForm int
+
+const (
+	pluralOne pluralForm = iota
+	pluralFew
+	pluralMany
+	pluralOther
+)
+
+// Message keys. Translations take the habit name
+// as the first argument and the number, or the date,
+// as the second one. The achievement message takes
+// the name and the description of the achievement.
+// Challenge messages take the habit name, the day or
+// the number of days done, and the days of the challenge.
+// The streak at risk message takes the habit name and
+// the streak of the habit due today.
+const (
+	msgStart       = "start"
+	msgStreak      = "streak"
+	msgBroken      = "broken"
+	msgRecordedOn  = "recorded on"
+	msgNewStreak   = "new streak"
+	msgContinued   = "continued"
+	msgMilestone   = "milestone"
+	msgNotTracking = "not tracking"
+	msgAchievement = "achievement"
+	msgAllDone     = "all done"
+	msgStreakRisk  = "streak at risk"
+
+	msgChallengeDay       = "challenge day"
+	msgChallengeCompleted = "challenge completed"
+	msgChallengeFailed    = "challenge failed"
+)
+
+// catalog holds messages of a language with variants
+// for plural categories, and the plural rule of the language.
+type catalog struct {
+	plural   func(n int) pluralForm
+	messages map[string]map[pluralForm]string
+}
+
+// pluralEnglish is the CLDR plural rule for integers in English and German.
+func pluralEnglish(n int) pluralForm {
+	if n == 1 {
+		return pluralOne
+	}
+	return pluralOther
+}
+
+// pluralPolish is the CLDR plural rule for integers in Polish.
+func pluralPolish(n int) pluralForm {
+	switch {
+	case n == 1:
+		return pluralOne
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return pluralFew
+	default:
+		return pluralMany
+	}
+}
+
+var catalogs = map[string]catalog{
+	"en": {
+		plural: pluralEnglish,
+		messages: map[string]map[pluralForm]string{
+			msgStart: {
+				pluralOther: "Good luck with your new habit '%[1]s'. Don't forget to do it tomorrow.\n",
+			},
+			msgStreak: {
+				pluralOther: "You're currently on a %[2]d-day streak for '%[1]s'. Stick to it!\n",
+			},
+			msgBroken: {
+				pluralOne:   "It's been %[2]d day since you did '%[1]s'. It's ok, life happens. Get back on that horse today!\n",
+				pluralOther: "It's been %[2]d days since you did '%[1]s'. It's ok, life happens. Get back on that horse today!\n",
+			},
+			msgRecordedOn: {
+				pluralOther: "Recorded the habit '%[1]s' on %[2]s.\n",
+			},
+			msgNewStreak: {
+				pluralOne:   "You last did the habit '%[1]s' %[2]d day ago, so you're starting a new streak today. Good luck!\n",
+				pluralOther: "You last did the habit '%[1]s' %[2]d days ago, so you're starting a new streak today. Good luck!\n",
+			},
+			msgContinued: {
+				pluralOne:   "Nice work: you've done the habit '%[1]s' for %[2]d day in a row now. Keep it up!\n",
+				pluralOther: "Nice work: you've done the habit '%[1]s' for %[2]d days in a row now. Keep it up!\n",
+			},
+			msgMilestone: {
+				pluralOther: "Milestone reached: you've done the habit '%[1]s' for %[2]d days in a row! Take a moment to celebrate.\n",
+			},
+			msgNotTracking: {
+				pluralOther: "You are not tracking any habit yet.\n",
+			},
+			msgAchievement: {
+				pluralOther: "Achievement unlocked: %[1]s%[2]s!\n",
+			},
+			msgAllDone: {
+				pluralOther: "All habits done for today.\n",
+			},
+			msgStreakRisk: {
+				pluralOther: "%[1]s (%[2]d-day streak at risk)\n",
+			},
+			msgChallengeDay: {
+				pluralOther: "Challenge '%[1]s': day %[2]d of %[3]d.\n",
+			},
+			msgChallengeCompleted: {
+				pluralOther: "Challenge '%[1]s' completed: you did it on all %[2]d days!\n",
+			},
+			msgChallengeFailed: {
+				pluralOther: "Challenge '%[1]s' is over: you did it on %[2]d of %[3]d days. Try again!\n",
+			},
+		},
+	},
+	"de": {
+		plural: pluralEnglish,
+		messages: map[string]map[pluralForm]string{
+			msgStart: {
+				pluralOther: "Viel Erfolg mit deiner neuen Gewohnheit '%[1]s'. Vergiss nicht, sie morgen wieder zu tun.\n",
+			},
+			msgStreak: {
+				pluralOne:   "Du bist gerade bei einer Serie von %[2]d Tag für '%[1]s'. Bleib dran!\n",
+				pluralOther: "Du bist gerade bei einer Serie von %[2]d Tagen für '%[1]s'. Bleib dran!\n",
+			},
+			msgBroken: {
+				pluralOne:   "Es ist %[2]d Tag her, dass du '%[1]s' gemacht hast. Nicht schlimm, das passiert. Steig heute wieder ein!\n",
+				pluralOther: "Es ist %[2]d Tage her, dass du '%[1]s' gemacht hast. Nicht schlimm, das passiert. Steig heute wieder ein!\n",
+			},
+			msgRecordedOn: {
+				pluralOther: "Gewohnheit '%[1]s' am %[2]s eingetragen.\n",
+			},
+			msgNewStreak: {
+				pluralOne:   "Du hast '%[1]s' zuletzt vor %[2]d Tag gemacht, also beginnst du heute eine neue Serie. Viel Erfolg!\n",
+				pluralOther: "Du hast '%[1]s' zuletzt vor %[2]d Tagen gemacht, also beginnst du heute eine neue Serie. Viel Erfolg!\n",
+			},
+			msgContinued: {
+				pluralOne:   "Gut gemacht: du hast '%[1]s' jetzt %[2]d Tag in Folge gemacht. Weiter so!\n",
+				pluralOther: "Gut gemacht: du hast '%[1]s' jetzt %[2]d Tage in Folge gemacht. Weiter so!\n",
+			},
+			msgMilestone: {
+				pluralOther: "Meilenstein erreicht: du hast '%[1]s' %[2]d Tage in Folge gemacht! Nimm dir einen Moment zum Feiern.\n",
+			},
+			msgNotTracking: {
+				pluralOther: "Du verfolgst noch keine Gewohnheit.\n",
+			},
+			msgAchievement: {
+				pluralOther: "Erfolg freigeschaltet: %[1]s%[2]s!\n",
+			},
+			msgAllDone: {
+				pluralOther: "Alle Gewohnheiten für heute erledigt.\n",
+			},
+			msgStreakRisk: {
+				pluralOne:   "%[1]s (Serie von %[2]d Tag in Gefahr)\n",
+				pluralOther: "%[1]s (Serie von %[2]d Tagen in Gefahr)\n",
+			},
+			msgChallengeDay: {
+				pluralOther: "Challenge '%[1]s': Tag %[2]d von %[3]d.\n",
+			},
+			msgChallengeCompleted: {
+				pluralOther: "Challenge '%[1]s' geschafft: du hast es an allen %[2]d Tagen gemacht!\n",
+			},
+			msgChallengeFailed: {
+				pluralOther: "Challenge '%[1]s' ist vorbei: du hast es an %[2]d von %[3]d Tagen gemacht. Versuch es noch einmal!\n",
+			},
+		},
+	},
+	"pl": {
+		plural: pluralPolish,
+		messages: map[string]map[pluralForm]string{
+			msgStart: {
+				pluralOther: "Powodzenia z nowym nawykiem '%[1]s'. Nie zapomnij o nim jutro.\n",
+			},
+			msgStreak: {
+				pluralOther: "Jesteś obecnie na %[2]d-dniowej serii nawyku '%[1]s'. Tak trzymaj!\n",
+			},
+			msgBroken: {
+				pluralOne:  "Minął %[2]d dzień od ostatniego wykonania nawyku '%[1]s'. Nic się nie stało, bywa. Wróć do niego dziś!\n",
+				pluralFew:  "Minęły %[2]d dni od ostatniego wykonania nawyku '%[1]s'. Nic się nie stało, bywa. Wróć do niego dziś!\n",
+				pluralMany: "Minęło %[2]d dni od ostatniego wykonania nawyku '%[1]s'. Nic się nie stało, bywa. Wróć do niego dziś!\n",
+			},
+			msgRecordedOn: {
+				pluralOther: "Zapisano nawyk '%[1]s' w dniu %[2]s.\n",
+			},
+			msgNewStreak: {
+				pluralOne:  "Ostatnio nawyk '%[1]s' był wykonany %[2]d dzień temu, więc dziś zaczynasz nową serię. Powodzenia!\n",
+				pluralFew:  "Ostatnio nawyk '%[1]s' był wykonany %[2]d dni temu, więc dziś zaczynasz nową serię. Powodzenia!\n",
+				pluralMany: "Ostatnio nawyk '%[1]s' był wykonany %[2]d dni temu, więc dziś zaczynasz nową serię. Powodzenia!\n",
+			},
+			msgContinued: {
+				pluralOne:  "Świetnie: wykonujesz nawyk '%[1]s' już %[2]d dzień z rzędu. Tak trzymaj!\n",
+				pluralFew:  "Świetnie: wykonujesz nawyk '%[1]s' już %[2]d dni z rzędu. Tak trzymaj!\n",
+				pluralMany: "Świetnie: wykonujesz nawyk '%[1]s' już %[2]d dni z rzędu. Tak trzymaj!\n",
+			},
+			msgMilestone: {
+				pluralOne:  "Kamień milowy: wykonujesz nawyk '%[1]s' już %[2]d dzień z rzędu! Świętuj!\n",
+				pluralFew:  "Kamień milowy: wykonujesz nawyk '%[1]s' już %[2]d dni z rzędu! Świętuj!\n",
+				pluralMany: "Kamień milowy: wykonujesz nawyk '%[1]s' już %[2]d dni z rzędu! Świętuj!\n",
+			},
+			msgNotTracking: {
+				pluralOther: "Nie śledzisz jeszcze żadnego nawyku.\n",
+			},
+			msgAchievement: {
+				pluralOther: "Osiągnięcie odblokowane: %[1]s%[2]s!\n",
+			},
+			msgAllDone: {
+				pluralOther: "Wszystkie nawyki na dziś wykonane.\n",
+			},
+			msgStreakRisk: {
+				pluralOne:  "%[1]s (zagrożona seria: %[2]d dzień)\n",
+				pluralFew:  "%[1]s (zagrożona seria: %[2]d dni)\n",
+				pluralMany: "%[1]s (zagrożona seria: %[2]d dni)\n",
+			},
+			msgChallengeDay: {
+				pluralOther: "Wyzwanie '%[1]s': dzień %[2]d z %[3]d.\n",
+			},
+			msgChallengeCompleted: {
+				pluralOther: "Wyzwanie '%[1]s' ukończone: nawyk wykonany każdego dnia z %[2]d!\n",
+			},
+			msgChallengeFailed: {
+				pluralOther: "Wyzwanie '%[1]s' zakończone: nawyk wykonany w %[2]d z %[3]d dni. Spróbuj jeszcze raz!\n",
+			},
+		},
+	},
+}
+
+// language holds the catalog of messages in use.
+var language = catalogs["en"]
+
+// Languages returns tags of languages messages are available in.
+func Languages() []string {
+	tags := make([]string, 0, len(catalogs))
+	for tag := range catalogs {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// SetLanguage takes a language tag, like "pl" or "de_DE.UTF-8",
+// and switches messages to the language. It returns an error
+// if messages are not available in the language.
+func SetLanguage(tag string) error {
+	c, ok := catalogs[baseLanguage(tag)]
+	if !ok {
+		return fmt.Errorf("unsupported language: %s, want one of: %s", tag, strings.Join(Languages(), ", "))
+	}
+	language = c
+	return nil
+}
+
+// LanguageFromEnv returns the language of messages selected
+// by environment variables LC_ALL, LC_MESSAGES and LANG, in
+// order of precedence. It returns "en" if none of them selects
+// an available language.
+func LanguageFromEnv() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := os.Getenv(name)
+		if v == "" {
+			continue
+		}
+		tag := baseLanguage(v)
+		if _, ok := catalogs[tag]; ok {
+			return tag
+		}
+		// A set variable takes precedence over the following
+		// ones even if it selects an unavailable language.
+		return "en"
+	}
+	return "en"
+}
+
+// baseLanguage returns the language part of a locale
+// name, for example "pl" for "pl_PL.UTF-8@euro".
+func baseLanguage(tag string) string {
+	tag, _, _ = strings.Cut(tag, ".")
+	tag, _, _ = strings.Cut(tag, "@")
+	tag, _, _ = strings.Cut(strings.ReplaceAll(tag, "-", "_"), "_")
+	return strings.ToLower(tag)
+}
+
+// translate returns the message with the given key in the language
+// in use, in the plural form matching n, formatted with args.
+// Missing translations fall back to the other plural form
+// and then to English.
+func translate(key string, n int, args ...any) string {
+	for _, c := range []catalog{language, catalogs["en"]} {
+		forms := c.messages[key]
+		if format, ok := forms[c.plural(n)]; ok {
+			return fmt.Sprintf(format, args...)
+		}
+		if format, ok := forms[pluralOther]; ok {
+			return fmt.Sprintf(format, args...)
+		}
+	}
+	return key
+}
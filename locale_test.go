@@ -0,0 +1,190 @@
+package habit_test
+
+import (
+	"testing"
+
+	"github.com/qba73/habit"
+)
+
+// messages records the habit and checks it so that
+// every message is printed with the given number.
+func messages(t *testing.T, n int) []string {
+	t.Helper()
+	var msgs []string
+
+	h := habit.Habit{Name: "jog"}
+	msgs = append(msgs, h.Start())
+
+	h = habit.Habit{Name: "jog", Date: day(1).Date, Streak: n}
+	setNow(t, day(1).Date)
+	_, msg := h.Check()
+	msgs = append(msgs, msg)
+
+	h = habit.Habit{Name: "jog", Date: day(1).Date, Streak: 1}
+	setNow(t, day(1).Date.AddDate(0, 0, n))
+	_, msg = h.Check()
+	msgs = append(msgs, msg)
+
+	_, msg = h.RecordOn(day(1).Date.AddDate(0, 0, n))
+	msgs = append(msgs, msg)
+
+	h = habit.Habit{Name: "jog", Date: day(1).Date, Streak: n - 1}
+	_, msg = h.RecordOn(day(2).Date)
+	msgs = append(msgs, msg)
+
+	h = habit.Habit{Name: "jog", Date: day(5).Date, Streak: 1}
+	_, msg = h.RecordOn(day(3).Date)
+	msgs = append(msgs, msg)
+	return msgs
+}
+
+func setLanguage(t *testing.T, tag string) {
+	t.Helper()
+	if err := habit.SetLanguage(tag); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { habit.SetLanguage("en") })
+}
+
+func TestMessages_English(t *testing.T) {
+	setLanguage(t, "en")
+
+	tt := map[int][]string{
+		2: {
+			"Good luck with your new habit 'jog'. Don't forget to do it tomorrow.\n",
+			"You're currently on a 2-day streak for 'jog'. Stick to it!\n",
+			"It's been 2 days since you did 'jog'. It's ok, life happens. Get back on that horse today!\n",
+			"You last did the habit 'jog' 2 days ago, so you're starting a new streak today. Good luck!\n",
+			"Nice work: you've done the habit 'jog' for 2 days in a row now. Keep it up!\n",
+			"Recorded the habit 'jog' on 2022-10-03.\n",
+		},
+		22: {
+			"Good luck with your new habit 'jog'. Don't forget to do it tomorrow.\n",
+			"You're currently on a 22-day streak for 'jog'. Stick to it!\n",
+			"It's been 22 days since you did 'jog'. It's ok, life happens. Get back on that horse today!\n",
+			"You last did the habit 'jog' 22 days ago, so you're starting a new streak today. Good luck!\n",
+			"Nice work: you've done the habit 'jog' for 22 days in a row now. Keep it up!\n",
+			"Recorded the habit 'jog' on 2022-10-03.\n",
+		},
+	}
+	for n, want := range tt {
+		assertMessages(t, n, want)
+	}
+}
+
+func TestMessages_German(t *testing.T) {
+	setLanguage(t, "de_DE.UTF-8")
+
+	tt := map[int][]string{
+		2: {
+			"Viel Erfolg mit deiner neuen Gewohnheit 'jog'. Vergiss nicht, sie morgen wieder zu tun.\n",
+			"Du bist gerade bei einer Serie von 2 Tagen für 'jog'. Bleib dran!\n",
+			"Es ist 2 Tage her, dass du 'jog' gemacht hast. Nicht schlimm, das passiert. Steig heute wieder ein!\n",
+			"Du hast 'jog' zuletzt vor 2 Tagen gemacht, also beginnst du heute eine neue Serie. Viel Erfolg!\n",
+			"Gut gemacht: du hast 'jog' jetzt 2 Tage in Folge gemacht. Weiter so!\n",
+			"Gewohnheit 'jog' am 2022-10-03 eingetragen.\n",
+		},
+		22: {
+			"Viel Erfolg mit deiner neuen Gewohnheit 'jog'. Vergiss nicht, sie morgen wieder zu tun.\n",
+			"Du bist gerade bei einer Serie von 22 Tagen für 'jog'. Bleib dran!\n",
+			"Es ist 22 Tage her, dass du 'jog' gemacht hast. Nicht schlimm, das passiert. Steig heute wieder ein!\n",
+			"Du hast 'jog' zuletzt vor 22 Tagen gemacht, also beginnst du heute eine neue Serie. Viel Erfolg!\n",
+			"Gut gemacht: du hast 'jog' jetzt 22 Tage in Folge gemacht. Weiter so!\n",
+			"Gewohnheit 'jog' am 2022-10-03 eingetragen.\n",
+		},
+	}
+	for n, want := range tt {
+		assertMessages(t, n, want)
+	}
+}
+
+func TestMessages_Polish(t *testing.T) {
+	setLanguage(t, "pl")
+
+	tt := map[int][]string{
+		2: {
+			"Powodzenia z nowym nawykiem 'jog'. Nie zapomnij o nim jutro.\n",
+			"Jesteś obecnie na 2-dniowej serii nawyku 'jog'. Tak trzymaj!\n",
+			"Minęły 2 dni od ostatniego wykonania nawyku 'jog'. Nic się nie stało, bywa. Wróć do niego dziś!\n",
+			"Ostatnio nawyk 'jog' był wykonany 2 dni temu, więc dziś zaczynasz nową serię. Powodzenia!\n",
+			"Świetnie: wykonujesz nawyk 'jog' już 2 dni z rzędu. Tak trzymaj!\n",
+			"Zapisano nawyk 'jog' w dniu 2022-10-03.\n",
+		},
+		5: {
+			"Powodzenia z nowym nawykiem 'jog'. Nie zapomnij o nim jutro.\n",
+			"Jesteś obecnie na 5-dniowej serii nawyku 'jog'. Tak trzymaj!\n",
+			"Minęło 5 dni od ostatniego wykonania nawyku 'jog'. Nic się nie stało, bywa. Wróć do niego dziś!\n",
+			"Ostatnio nawyk 'jog' był wykonany 5 dni temu, więc dziś zaczynasz nową serię. Powodzenia!\n",
+			"Świetnie: wykonujesz nawyk 'jog' już 5 dni z rzędu. Tak trzymaj!\n",
+			"Zapisano nawyk 'jog' w dniu 2022-10-03.\n",
+		},
+		12: {
+			"Powodzenia z nowym nawykiem 'jog'. Nie zapomnij o nim jutro.\n",
+			"Jesteś obecnie na 12-dniowej serii nawyku 'jog'. Tak trzymaj!\n",
+			"Minęło 12 dni od ostatniego wykonania nawyku 'jog'. Nic się nie stało, bywa. Wróć do niego dziś!\n",
+			"Ostatnio nawyk 'jog' był wykonany 12 dni temu, więc dziś zaczynasz nową serię. Powodzenia!\n",
+			"Świetnie: wykonujesz nawyk 'jog' już 12 dni z rzędu. Tak trzymaj!\n",
+			"Zapisano nawyk 'jog' w dniu 2022-10-03.\n",
+		},
+		22: {
+			"Powodzenia z nowym nawykiem 'jog'. Nie zapomnij o nim jutro.\n",
+			"Jesteś obecnie na 22-dniowej serii nawyku 'jog'. Tak trzymaj!\n",
+			"Minęły 22 dni od ostatniego wykonania nawyku 'jog'. Nic się nie stało, bywa. Wróć do niego dziś!\n",
+			"Ostatnio nawyk 'jog' był wykonany 22 dni temu, więc dziś zaczynasz nową serię. Powodzenia!\n",
+			"Świetnie: wykonujesz nawyk 'jog' już 22 dni z rzędu. Tak trzymaj!\n",
+			"Zapisano nawyk 'jog' w dniu 2022-10-03.\n",
+		},
+	}
+	for n, want := range tt {
+		assertMessages(t, n, want)
+	}
+}
+
+func assertMessages(t *testing.T, n int, want []string) {
+	t.Helper()
+	got := messages(t, n)
+	for i := range want {
+		if want[i] != got[i] {
+			t.Errorf("n=%d: want %q, got %q", n, want[i], got[i])
+		}
+	}
+}
+
+func TestCheck_UsesSingularFormForOneDayStreak(t *testing.T) {
+	setLanguage(t, "de")
+	setNow(t, day(2).Date)
+
+	h := habit.Habit{Name: "jog", Date: day(1).Date, Streak: 1}
+	want := "Du bist gerade bei einer Serie von 1 Tag für 'jog'. Bleib dran!\n"
+	_, got := h.Check()
+	if want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestSetLanguage_ErrorsOnUnsupportedLanguage(t *testing.T) {
+	if err := habit.SetLanguage("xx_XX.UTF-8"); err == nil {
+		t.Error("want error, got nil")
+	}
+}
+
+func TestLanguageFromEnv_FollowsPrecedenceOfLocaleVariables(t *testing.T) {
+	tt := []struct {
+		all, messages, lang string
+		want                string
+	}{
+		{want: "en"},
+		{lang: "pl_PL.UTF-8", want: "pl"},
+		{messages: "de_DE.UTF-8", lang: "pl_PL.UTF-8", want: "de"},
+		{all: "C", messages: "de_DE.UTF-8", lang: "pl_PL.UTF-8", want: "en"},
+		{lang: "fr_FR.UTF-8", want: "en"},
+	}
+	for _, tc := range tt {
+		t.Setenv("LC_ALL", tc.all)
+		t.Setenv("LC_MESSAGES", tc.messages)
+		t.Setenv("LANG", tc.lang)
+		if got := habit.LanguageFromEnv(); tc.want != got {
+			t.Errorf("LC_ALL=%q LC_MESSAGES=%q LANG=%q: want %q, got %q", tc.all, tc.messages, tc.lang, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package habit
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// storeStamp identifies a version of the store file.
+type storeStamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func statStore(path string) (storeStamp, error) {
+	fi, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return storeStamp{}, nil
+	}
+	if err != nil {
+		return storeStamp{}, err
+	}
+	return storeStamp{exists: true, size: fi.Size(), modTime: fi.ModTime()}, nil
+}
+
+// Lock takes an exclusive lock of the store, waiting while another
+// process holds it, and reloads habits if the file changed since
+// it was read or written last, so changes made meanwhile by other
+// processes, like habit serve and the command line, are not lost.
+// Call Unlock once the store is saved.
+//
+// The lock is held on the file with the store's path and the .lock
+// suffix, so it survives the store file being rewritten.
+func (f *FileStore) Lock() error {
+	lf, err := os.OpenFile(f.Path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if errors.Is(err, fs.ErrNotExist) {
+		// The directory of a new store doesn't exist yet,
+		// so no other process can use the store.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := lockFile(lf); err != nil {
+		lf.Close()
+		return err
+	}
+	f.lock = lf
+	stamp, err := statStore(f.Path)
+	if err == nil && stamp != f.stamp {
+		err = f.load()
+	}
+	if err != nil {
+		f.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Unlock releases the lock taken by Lock.
+func (f *FileStore) Unlock() error {
+	if f.lock == nil {
+		return nil
+	}
+	err := unlockFile(f.lock)
+	if cerr := f.lock.Close(); err == nil {
+		err = cerr
+	}
+	f.lock = nil
+	return err
+}
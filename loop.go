@@ -0,0 +1,236 @@
+package habit
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loopYesManual is the value Loop Habit Tracker uses
+// for days when the habit was checked by the user.
+const loopYesManual = 2
+
+// ImportLoop reads habits and checkmarks exported to CSV files
+// by the Loop Habit Tracker Android app and returns habits
+// with their history. Streaks are rebuilt from the history.
+//
+// Only days checked by the user become completions. Days Loop
+// marks as done automatically, because of the habit frequency,
+// are skipped, as are habits never checked. Habit frequency
+// is kept in habit's Frequency and streaks of habits with a
+// frequency allow days of rest between the days checked.
+func ImportLoop(habitsCSV, checkmarksCSV io.Reader) ([]Habit, error) {
+	habits, err := readLoopHabits(habitsCSV)
+	if err != nil {
+		return nil, fmt.Errorf("reading Loop habits: %w", err)
+	}
+
+	cr := csv.NewReader(checkmarksCSV)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading Loop checkmarks: %w", err)
+	}
+	if len(header) == 0 || !strings.EqualFold(strings.TrimSpace(header[0]), "Date") {
+		return nil, errors.New("reading Loop checkmarks: line 1: missing Date column")
+	}
+	columns := make([]*Habit, len(header))
+	for i, name := range header[1:] {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		h, ok := habits[name]
+		if !ok {
+			return nil, fmt.Errorf("reading Loop checkmarks: line 1: unknown habit %q", name)
+		}
+		columns[i+1] = h
+	}
+
+	var errs []error
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading Loop checkmarks: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		date, err := time.Parse(dateLayout, strings.TrimSpace(record[0]))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: invalid date %q", line, record[0]))
+			continue
+		}
+		for i, value := range record[1:] {
+			if i+1 >= len(columns) || columns[i+1] == nil {
+				continue
+			}
+			h := columns[i+1]
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			v, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("line %d: invalid checkmark %q for habit %q", line, value, h.Name))
+				continue
+			}
+			if v == loopYesManual {
+				h.History = append(h.History, Completion{Date: date})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("reading Loop checkmarks: %w", errors.Join(errs...))
+	}
+
+	return loopHabits(habits), nil
+}
+
+// loopHabits returns habits done at least once, sorted by
+// name, with their history sorted and streaks rebuilt.
+func loopHabits(habits map[string]*Habit) []Habit {
+	hx := make([]Habit, 0, len(habits))
+	for _, h := range habits {
+		if len(h.History) == 0 {
+			continue
+		}
+		h.History = mergeCompletions(h.History, nil)
+		h.rebuildStreak()
+		hx = append(hx, *h)
+	}
+	sort.Slice(hx, func(i, j int) bool { return hx[i].Name < hx[j].Name })
+	return hx
+}
+
+// readLoopHabits reads Loop's Habits.csv and returns
+// habits by name with their frequency set.
+func readLoopHabits(r io.Reader) (map[string]*Habit, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	col := make(map[string]int)
+	for i, name := range header {
+		col[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"Name", "NumRepetitions", "Interval"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("line 1: missing %s column", name)
+		}
+	}
+
+	habits := make(map[string]*Habit)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return habits, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		field := func(name string) string {
+			if col[name] >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[col[name]])
+		}
+		name := field("Name")
+		if name == "" {
+			return nil, fmt.Errorf("line %d: name cannot be empty", line)
+		}
+		times, err := strconv.Atoi(field("NumRepetitions"))
+		if err != nil || times < 1 {
+			return nil, fmt.Errorf("line %d: invalid number of repetitions %q", line, field("NumRepetitions"))
+		}
+		days, err := strconv.Atoi(field("Interval"))
+		if err != nil || days < 1 {
+			return nil, fmt.Errorf("line %d: invalid interval %q", line, field("Interval"))
+		}
+		h := &Habit{Name: name}
+		if times != days {
+			h.Frequency = &Frequency{Times: times, Days: days}
+		}
+		habits[name] = h
+	}
+}
+
+// importLoopFiles imports Loop habits from paths given on the
+// command line: a database backup, a directory or a zip file
+// with the CSV export, or Habits.csv followed by Checkmarks.csv.
+func importLoopFiles(paths []string) ([]Habit, error) {
+	switch {
+	case len(paths) == 2:
+		habits, err := os.Open(paths[0])
+		if err != nil {
+			return nil, err
+		}
+		defer habits.Close()
+		checkmarks, err := os.Open(paths[1])
+		if err != nil {
+			return nil, err
+		}
+		defer checkmarks.Close()
+		return ImportLoop(habits, checkmarks)
+	case len(paths) != 1:
+		return nil, errors.New("usage: habit import --from loop <backup.db | export.zip | export dir | Habits.csv Checkmarks.csv>")
+	case strings.HasSuffix(paths[0], ".db"):
+		f, err := os.Open(paths[0])
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ImportLoopDB(f)
+	case strings.HasSuffix(paths[0], ".zip"):
+		return importLoopZip(paths[0])
+	}
+	return importLoopFiles([]string{
+		filepath.Join(paths[0], "Habits.csv"),
+		filepath.Join(paths[0], "Checkmarks.csv"),
+	})
+}
+
+func importLoopZip(name string) ([]Habit, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	// Loop keeps checkmarks of each habit also in subdirectories,
+	// only the top level files are needed.
+	var habits, checkmarks *zip.File
+	for _, f := range zr.File {
+		switch f.Name {
+		case "Habits.csv":
+			habits = f
+		case "Checkmarks.csv":
+			checkmarks = f
+		}
+	}
+	if habits == nil || checkmarks == nil {
+		return nil, fmt.Errorf("%s: missing Habits.csv or Checkmarks.csv", name)
+	}
+	hr, err := habits.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer hr.Close()
+	cr, err := checkmarks.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer cr.Close()
+	return ImportLoop(hr, cr)
+}
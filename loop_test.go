@@ -0,0 +1,195 @@
+package habit_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/habit"
+)
+
+//go:generate sh -c "rm -f testdata/loop.db && sqlite3 testdata/loop.db < testdata/loop.sql"
+
+const loopHabits = `Position,Name,Question,Description,NumRepetitions,Interval,Color
+001,Jog,Did you jog today?,,1,1,#FF8F00
+002,Gym,,,3,7,#00897B
+003,Meditate,,,1,1,#00897B
+`
+
+const loopCheckmarks = `Date,Jog,Gym,Meditate,
+2022-10-05,2,1,-1,
+2022-10-04,2,2,-1,
+2022-10-03,0,1,-1,
+2022-10-02,2,2,0,
+`
+
+func TestImportLoop_RebuildsHabitsFromCheckmarks(t *testing.T) {
+	t.Parallel()
+
+	got, err := habit.ImportLoop(strings.NewReader(loopHabits), strings.NewReader(loopCheckmarks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []habit.Habit{
+		{
+			Name:      "Gym",
+			Date:      day(4).Date,
+			Streak:    2,
+			History:   []habit.Completion{day(2), day(4)},
+			Frequency: &habit.Frequency{Times: 3, Days: 7},
+		},
+		{
+			Name:    "Jog",
+			Date:    day(5).Date,
+			Streak:  2,
+			History: []habit.Completion{day(2), day(4), day(5)},
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestImportLoop_ErrorsOnCheckmarksOfUnknownHabit(t *testing.T) {
+	t.Parallel()
+
+	checkmarks := "Date,Swim,\n2022-10-05,2,\n"
+	_, err := habit.ImportLoop(strings.NewReader(loopHabits), strings.NewReader(checkmarks))
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+}
+
+func TestImportLoop_ReportsInvalidLines(t *testing.T) {
+	t.Parallel()
+
+	checkmarks := "Date,Jog,\n2022-10-05,2,\nyesterday,2,\n2022-10-03,yes,\n"
+	_, err := habit.ImportLoop(strings.NewReader(loopHabits), strings.NewReader(checkmarks))
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+	for _, want := range []string{`line 3: invalid date "yesterday"`, `line 4: invalid checkmark "yes"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("want error containing %q, got %q", want, err)
+		}
+	}
+}
+
+func TestImportLoopDB_RebuildsHabitsFromRepetitions(t *testing.T) {
+	t.Parallel()
+
+	// testdata/loop.db is generated from testdata/loop.sql.
+	f, err := os.Open("testdata/loop.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := habit.ImportLoopDB(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("want 4 habits, got %d", len(got))
+	}
+	stretch := got[3]
+	first, last := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2022, 2, 8, 0, 0, 0, 0, time.UTC)
+	if len(stretch.History) != 1500 || !stretch.History[0].Date.Equal(first) || stretch.Streak != 1500 || !stretch.Date.Equal(last) {
+		t.Errorf("want 1500-day streak of Stretch from %s to %s, got %d days, streak %d ending %s", first, last, len(stretch.History), stretch.Streak, stretch.Date)
+	}
+	want := []habit.Habit{
+		{
+			Name:      "Gym",
+			Date:      day(10).Date,
+			Streak:    4,
+			History:   []habit.Completion{day(3), day(5), day(7), day(10)},
+			Frequency: &habit.Frequency{Times: 3, Days: 7},
+		},
+		{
+			Name:    "Jog",
+			Date:    day(5).Date,
+			Streak:  4,
+			History: days(2, 5),
+		},
+		{
+			Name:    "Read",
+			Date:    day(4).Date,
+			Streak:  1,
+			History: []habit.Completion{{Date: day(4).Date, Amount: 12.5}},
+		},
+	}
+	if !cmp.Equal(want, got[:3]) {
+		t.Error(cmp.Diff(want, got[:3]))
+	}
+}
+
+func TestImportLoopDB_ErrorsOnInvalidDatabase(t *testing.T) {
+	t.Parallel()
+
+	if _, err := habit.ImportLoopDB(strings.NewReader("not a database")); err == nil {
+		t.Error("want error on invalid database")
+	}
+	data, err := os.ReadFile("testdata/loop.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := habit.ImportLoopDB(bytes.NewReader(data[:len(data)/2])); err == nil {
+		t.Error("want error on truncated database")
+	}
+}
+
+// corruptLoopDB returns testdata/loop.db with the bytes at
+// the offset replaced.
+func corruptLoopDB(t *testing.T, offset int, b ...byte) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/loop.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(data[offset:], b)
+	return data
+}
+
+func TestImportLoopDB_ErrorsOnCorruptDatabase(t *testing.T) {
+	t.Parallel()
+
+	// Pages 3 and 5 are interior root pages of Habits
+	// and Repetitions; pages are 4096 bytes long.
+	tt := map[string]struct {
+		offset int
+		b      []byte
+		want   string
+	}{
+		"invalid page size":        {offset: 16, b: []byte{0x03, 0x00}, want: "invalid page size"},
+		"UTF-16 database":          {offset: 56, b: []byte{0, 0, 0, 2}, want: "only UTF-8"},
+		"cycle in b-tree":          {offset: 4*4096 + 8, b: []byte{0, 0, 0, 5}, want: "cycle in b-tree"},
+		"child page out of file":   {offset: 4*4096 + 8, b: []byte{0, 0, 0xff, 0xff}, want: "out of the database"},
+		"unexpected page type":     {offset: 2 * 4096, b: []byte{0x0a}, want: "unexpected page type"},
+		"too many cells":           {offset: 2*4096 + 3, b: []byte{0xff, 0xff}, want: "invalid number of cells"},
+		"cell pointer out of page": {offset: 2*4096 + 12, b: []byte{0xff, 0xf0}, want: "cell out of the page"},
+	}
+	for name, tc := range tt {
+		_, err := habit.ImportLoopDB(bytes.NewReader(corruptLoopDB(t, tc.offset, tc.b...)))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: want error containing %q, got %v", name, tc.want, err)
+		}
+	}
+}
+
+func FuzzImportLoopDB(f *testing.F) {
+	data, err := os.ReadFile("testdata/loop.db")
+	if err != nil {
+		f.Fatal(err)
+	}
+	// The fuzzer mutates the first pages, holding the header,
+	// the schema, the habits and the root of repetitions.
+	f.Add(data[:5*4096])
+	f.Add(data[:100])
+	f.Fuzz(func(t *testing.T, head []byte) {
+		db := append(append([]byte{}, head...), data[min(len(head), len(data)):]...)
+		// Corrupt databases must be reported, not crash or hang.
+		habit.ImportLoopDB(bytes.NewReader(db))
+	})
+}
@@ -0,0 +1,429 @@
+package habit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// loopTypeNumerical is the type of Loop habits measured with
+// a number, like pages read, instead of a checkmark.
+const loopTypeNumerical = 1
+
+// ImportLoopDB reads a database backup of the Loop Habit Tracker
+// Android app and returns habits with their history, like ImportLoop.
+//
+// Days checked by the user become completions. Days of habits
+// measured with a number become completions with the amount
+// entered, if it's above zero.
+func ImportLoopDB(r io.Reader) ([]Habit, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	db, err := openSQLite(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading Loop database: %w", err)
+	}
+	habitRows, err := db.table("Habits")
+	if err != nil {
+		return nil, fmt.Errorf("reading Loop habits: %w", err)
+	}
+	byID := make(map[int64]*Habit)
+	numerical := make(map[int64]bool)
+	habits := make(map[string]*Habit)
+	for _, row := range habitRows {
+		name := strings.TrimSpace(row.text("name"))
+		if name == "" {
+			return nil, fmt.Errorf("reading Loop habits: habit %d: name cannot be empty", row.int("id"))
+		}
+		h, ok := habits[name]
+		if !ok {
+			h = &Habit{Name: name}
+			habits[name] = h
+		}
+		times, days := int(row.int("freq_num")), int(row.int("freq_den"))
+		if times >= 1 && days >= 1 && times != days {
+			h.Frequency = &Frequency{Times: times, Days: days}
+		}
+		byID[row.int("id")] = h
+		numerical[row.int("id")] = row.int("type") == loopTypeNumerical
+	}
+
+	repRows, err := db.table("Repetitions")
+	if err != nil {
+		return nil, fmt.Errorf("reading Loop repetitions: %w", err)
+	}
+	for _, row := range repRows {
+		id := row.int("habit")
+		h, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("reading Loop repetitions: repetition %d: unknown habit %d", row.int("id"), id)
+		}
+		date := time.UnixMilli(row.int("timestamp")).UTC()
+		// Databases of old Loop versions have no values, every
+		// repetition there is a day checked by the user.
+		value, ok := row.value("value")
+		v, _ := value.(int64)
+		switch {
+		case !ok || value == nil:
+			h.History = append(h.History, Completion{Date: date})
+		case numerical[id] && v > 0:
+			// Loop keeps amounts multiplied by 1000.
+			h.History = append(h.History, Completion{Date: date, Amount: float64(v) / 1000})
+		case !numerical[id] && v == loopYesManual:
+			h.History = append(h.History, Completion{Date: date})
+		}
+	}
+	return loopHabits(habits), nil
+}
+
+// sqliteDB reads tables of an SQLite database file, following
+// https://www.sqlite.org/fileformat.html. It's only as complete
+// as reading Loop backups needs: it reads table b-trees of UTF-8
+// databases and ignores indexes, the journal and the WAL file.
+type sqliteDB struct {
+	data     []byte
+	pageSize int
+	usable   int // usable holds the size of pages without the reserved space.
+}
+
+func openSQLite(data []byte) (*sqliteDB, error) {
+	if len(data) < 100 || !bytes.HasPrefix(data, []byte("SQLite format 3\x00")) {
+		return nil, errors.New("not an SQLite database")
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("invalid page size %d", pageSize)
+	}
+	if enc := binary.BigEndian.Uint32(data[56:60]); enc > 1 {
+		return nil, errors.New("only UTF-8 databases are supported")
+	}
+	db := &sqliteDB{data: data, pageSize: pageSize, usable: pageSize - int(data[20])}
+	if db.usable < 480 {
+		return nil, errors.New("invalid reserved space of pages")
+	}
+	return db, nil
+}
+
+// page returns the page with the given number, counted from 1.
+func (db *sqliteDB) page(n uint32) ([]byte, error) {
+	start := (int64(n) - 1) * int64(db.pageSize)
+	if n == 0 || start+int64(db.pageSize) > int64(len(db.data)) {
+		return nil, fmt.Errorf("page %d out of the database", n)
+	}
+	return db.data[start : start+int64(db.pageSize)], nil
+}
+
+// sqliteRow holds values of a table row by column name.
+type sqliteRow map[string]any
+
+func (r sqliteRow) value(column string) (any, bool) {
+	v, ok := r[strings.ToLower(column)]
+	return v, ok
+}
+
+func (r sqliteRow) int(column string) int64 {
+	v, _ := r.value(column)
+	switch v := v.(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+func (r sqliteRow) text(column string) string {
+	v, _ := r.value(column)
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
+
+// table returns rows of the table with the given name,
+// matched regardless of case, in order of their row ids.
+func (db *sqliteDB) table(name string) ([]sqliteRow, error) {
+	var schema [][]any
+	if err := db.walk(1, make(map[uint32]bool), func(rowid int64, values []any) { schema = append(schema, values) }); err != nil {
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+	for _, values := range schema {
+		if len(values) < 5 {
+			continue
+		}
+		typ, _ := values[0].(string)
+		tbl, _ := values[1].(string)
+		if typ != "table" || !strings.EqualFold(tbl, name) {
+			continue
+		}
+		root, _ := values[3].(int64)
+		sql, _ := values[4].(string)
+		columns, rowidColumn := sqliteColumns(sql)
+		var rows []sqliteRow
+		err := db.walk(uint32(root), make(map[uint32]bool), func(rowid int64, values []any) {
+			row := make(sqliteRow, len(columns))
+			for i, c := range columns {
+				if i < len(values) {
+					row[c] = values[i]
+				}
+			}
+			if rowidColumn != "" {
+				row[rowidColumn] = rowid
+			}
+			rows = append(rows, row)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reading table %s: %w", name, err)
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("missing table %s", name)
+}
+
+// sqliteColumns returns lower case names of columns defined in the
+// CREATE TABLE statement and the name of the column aliasing the
+// row id, if there is one.
+func sqliteColumns(sql string) ([]string, string) {
+	open, end := strings.Index(sql, "("), strings.LastIndex(sql, ")")
+	if open < 0 || end < open {
+		return nil, ""
+	}
+	var defs []string
+	depth, from := 0, open+1
+	for i := open + 1; i < end; i++ {
+		switch sql[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				defs = append(defs, sql[from:i])
+				from = i + 1
+			}
+		}
+	}
+	defs = append(defs, sql[from:end])
+
+	var columns []string
+	rowidColumn := ""
+	for _, def := range defs {
+		fields := strings.Fields(def)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "PRIMARY", "UNIQUE", "CHECK", "FOREIGN", "CONSTRAINT":
+			continue
+		}
+		name := strings.ToLower(strings.Trim(fields[0], "\"`[]'"))
+		columns = append(columns, name)
+		upper := strings.ToUpper(strings.Join(fields[1:], " "))
+		if strings.HasPrefix(upper, "INTEGER PRIMARY KEY") {
+			rowidColumn = name
+		}
+	}
+	return columns, rowidColumn
+}
+
+// walk calls fn with the row id and the values of every row of
+// the table b-tree with the root on the given page, in order.
+//
+// Pages are visited once, so corrupt b-trees
+// with cycles can't make it loop forever.
+func (db *sqliteDB) walk(n uint32, seen map[uint32]bool, fn func(rowid int64, values []any)) error {
+	if seen[n] {
+		return fmt.Errorf("page %d: cycle in b-tree", n)
+	}
+	seen[n] = true
+	page, err := db.page(n)
+	if err != nil {
+		return err
+	}
+	header := 0
+	if n == 1 {
+		header = 100
+	}
+	if len(page) < header+12 {
+		return fmt.Errorf("page %d too short", n)
+	}
+	kind := page[header]
+	cells := int(binary.BigEndian.Uint16(page[header+3:]))
+	switch kind {
+	case 0x05: // interior table page
+		pointers := page[header+12:]
+		if len(pointers) < 2*cells {
+			return fmt.Errorf("page %d: invalid number of cells", n)
+		}
+		for i := 0; i < cells; i++ {
+			off := int(binary.BigEndian.Uint16(pointers[2*i:]))
+			if off+4 > len(page) {
+				return fmt.Errorf("page %d: cell out of the page", n)
+			}
+			if err := db.walk(binary.BigEndian.Uint32(page[off:]), seen, fn); err != nil {
+				return err
+			}
+		}
+		return db.walk(binary.BigEndian.Uint32(page[header+8:]), seen, fn)
+	case 0x0d: // leaf table page
+		pointers := page[header+8:]
+		if len(pointers) < 2*cells {
+			return fmt.Errorf("page %d: invalid number of cells", n)
+		}
+		for i := 0; i < cells; i++ {
+			off := int(binary.BigEndian.Uint16(pointers[2*i:]))
+			if off >= len(page) {
+				return fmt.Errorf("page %d: cell out of the page", n)
+			}
+			rowid, payload, err := db.cell(page[off:])
+			if err != nil {
+				return fmt.Errorf("page %d: %w", n, err)
+			}
+			values, err := sqliteRecord(payload)
+			if err != nil {
+				return fmt.Errorf("page %d: %w", n, err)
+			}
+			fn(rowid, values)
+		}
+		return nil
+	}
+	return fmt.Errorf("page %d: unexpected page type %#x", n, kind)
+}
+
+// cell returns the row id and the payload of a leaf table
+// cell, reading the part of the payload on overflow pages.
+func (db *sqliteDB) cell(b []byte) (int64, []byte, error) {
+	size, n := sqliteVarint(b)
+	if n == 0 {
+		return 0, nil, errors.New("invalid cell")
+	}
+	b = b[n:]
+	rowid, n := sqliteVarint(b)
+	if n == 0 {
+		return 0, nil, errors.New("invalid cell")
+	}
+	b = b[n:]
+	if size < 0 || size > int64(len(db.data)) {
+		return 0, nil, errors.New("invalid payload size")
+	}
+	total := int(size)
+	local := total
+	if maxLocal := db.usable - 35; total > maxLocal {
+		minLocal := (db.usable-12)*32/255 - 23
+		local = minLocal + (total-minLocal)%(db.usable-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if len(b) < local {
+		return 0, nil, errors.New("payload out of the page")
+	}
+	payload := append([]byte{}, b[:local]...)
+	if local == total {
+		return rowid, payload, nil
+	}
+	if len(b) < local+4 {
+		return 0, nil, errors.New("missing overflow page")
+	}
+	next := binary.BigEndian.Uint32(b[local:])
+	for len(payload) < total {
+		page, err := db.page(next)
+		if err != nil {
+			return 0, nil, fmt.Errorf("overflow: %w", err)
+		}
+		chunk := page[4:db.usable]
+		if rest := total - len(payload); len(chunk) > rest {
+			chunk = chunk[:rest]
+		}
+		payload = append(payload, chunk...)
+		next = binary.BigEndian.Uint32(page)
+	}
+	return rowid, payload, nil
+}
+
+// sqliteVarint decodes an SQLite variable length integer and returns
+// it with the number of bytes read, or 0 if b is too short.
+func sqliteVarint(b []byte) (int64, int) {
+	var v uint64
+	for i := 0; i < 9; i++ {
+		if i >= len(b) {
+			return 0, 0
+		}
+		if i == 8 {
+			return int64(v<<8 | uint64(b[i])), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i] < 0x80 {
+			return int64(v), i + 1
+		}
+	}
+	return 0, 0
+}
+
+// sqliteRecord decodes values of a record: nil, int64,
+// float64, string or []byte.
+func sqliteRecord(b []byte) ([]any, error) {
+	headerSize, n := sqliteVarint(b)
+	if n == 0 || headerSize < int64(n) || headerSize > int64(len(b)) {
+		return nil, errors.New("invalid record header")
+	}
+	header, body := b[n:headerSize], b[headerSize:]
+	var values []any
+	for len(header) > 0 {
+		typ, n := sqliteVarint(header)
+		if n == 0 {
+			return nil, errors.New("invalid record header")
+		}
+		header = header[n:]
+		size := 0
+		switch {
+		case typ >= 1 && typ <= 4:
+			size = int(typ)
+		case typ == 5:
+			size = 6
+		case typ == 6 || typ == 7:
+			size = 8
+		case typ >= 12:
+			size = int((typ - 12) / 2)
+		}
+		if size > len(body) {
+			return nil, errors.New("record out of the payload")
+		}
+		field := body[:size]
+		body = body[size:]
+		switch {
+		case typ == 0:
+			values = append(values, nil)
+		case typ >= 1 && typ <= 6:
+			v := int64(int8(field[0]))
+			for _, c := range field[1:] {
+				v = v<<8 | int64(c)
+			}
+			values = append(values, v)
+		case typ == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(field)))
+		case typ == 8 || typ == 9:
+			values = append(values, typ-8)
+		case typ >= 12 && typ%2 == 0:
+			values = append(values, append([]byte{}, field...))
+		case typ >= 13:
+			values = append(values, string(field))
+		default:
+			return nil, fmt.Errorf("invalid serial type %d", typ)
+		}
+	}
+	return values, nil
+}
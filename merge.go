@@ -0,0 +1,289 @@
+package habit
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// MergeConflict describes a field of a habit, or of a completion,
+// holding different values in merged stores.
+type MergeConflict struct {
+	Habit    string
+	Day      time.Time // Day holds the day of the conflicting completion, zero for fields of the habit.
+	Field    string
+	Ours     string
+	Theirs   string
+	Resolved string // Resolved holds the value kept in the merged habit.
+}
+
+func (c MergeConflict) String() string {
+	if c.Day.IsZero() {
+		return fmt.Sprintf("habit '%s': %s %q differs from %q, kept %q", c.Habit, c.Field, c.Ours, c.Theirs, c.Resolved)
+	}
+	return fmt.Sprintf("habit '%s' on %s: %s %q differs from %q, kept %q", c.Habit, c.Day.Format(dateLayout), c.Field, c.Ours, c.Theirs, c.Resolved)
+}
+
+// Merge takes two stores and returns habits of both of them,
+// merged as by MergeHabits, and conflicts found when merging.
+// It doesn't modify the stores.
+func Merge(ours, theirs Store) ([]Habit, []MergeConflict) {
+	return MergeHabits(ours.GetAll(), theirs.GetAll())
+}
+
+// MergeHabits takes two sets of habits and returns their union
+// sorted by name. Completions of habits tracked in both sets are
+// merged day by day and streaks are rebuilt from them.
+//
+// Conflicting values are resolved the same way regardless
+// of the order of the sets, so stores synced both ways end
+// up equal:
+//
+//   - a frequency requiring more completions per day wins,
+//     a missing frequency is not a conflict
+//   - milestones of both habits are kept
+//   - achievements of both habits are kept, with the earlier date
+//   - past challenges of both habits are kept, and of challenges
+//     going on the one starting earlier wins
+//   - a larger amount of a completion wins
+//   - a longer note of a completion wins, or the one sorted
+//     first if notes are equally long
+//
+// It returns conflicts found, ordered by habit and day.
+func MergeHabits(ours, theirs []Habit) ([]Habit, []MergeConflict) {
+	habits := make(map[string]Habit)
+	for _, h := range ours {
+		habits[h.Name] = h
+	}
+	var conflicts []MergeConflict
+	for _, t := range theirs {
+		o, ok := habits[t.Name]
+		if !ok {
+			habits[t.Name] = t
+			continue
+		}
+		var cx []MergeConflict
+		o, cx = mergeHabit(o, t)
+		conflicts = append(conflicts, cx...)
+		habits[t.Name] = o
+	}
+
+	hx := make([]Habit, 0, len(habits))
+	for _, h := range habits {
+		hx = append(hx, h)
+	}
+	sort.Slice(hx, func(i, j int) bool { return hx[i].Name < hx[j].Name })
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		if conflicts[i].Habit != conflicts[j].Habit {
+			return conflicts[i].Habit < conflicts[j].Habit
+		}
+		return conflicts[i].Day.Before(conflicts[j].Day)
+	})
+	return hx, conflicts
+}
+
+func mergeHabit(o, t Habit) (Habit, []MergeConflict) {
+	var conflicts []MergeConflict
+	switch {
+	case o.Frequency == nil:
+		o.Frequency = t.Frequency
+	case t.Frequency != nil && *o.Frequency != *t.Frequency:
+		c := MergeConflict{Habit: o.Name, Field: "frequency", Ours: o.Frequency.String(), Theirs: t.Frequency.String()}
+		if t.Frequency.stricter(*o.Frequency) {
+			o.Frequency = t.Frequency
+		}
+		c.Resolved = o.Frequency.String()
+		conflicts = append(conflicts, c)
+	}
+	switch {
+	case o.Milestones == nil:
+		o.Milestones = t.Milestones
+	case t.Milestones != nil && !slices.Equal(o.Milestones, t.Milestones):
+		c := MergeConflict{Habit: o.Name, Field: "milestones", Ours: formatMilestones(o.Milestones), Theirs: formatMilestones(t.Milestones)}
+		o.Milestones = normalizeMilestones(append(append([]int{}, o.Milestones...), t.Milestones...))
+		c.Resolved = formatMilestones(o.Milestones)
+		conflicts = append(conflicts, c)
+	}
+	o.Achievements = mergeAchievements(o.Achievements, t.Achievements)
+	o.PastChallenges = mergeChallenges(o.PastChallenges, t.PastChallenges)
+	if o.Challenge == nil || t.Challenge != nil && t.Challenge.Start.Before(o.Challenge.Start) {
+		o.Challenge = t.Challenge
+	}
+	// A challenge ended in one store and going on in the other one
+	// is kept as ended.
+	for _, c := range o.PastChallenges {
+		if o.Challenge != nil && c.Start.Equal(o.Challenge.Start) {
+			o.Challenge = nil
+		}
+	}
+
+	days := make(map[time.Time]Completion)
+	for _, c := range o.Completions() {
+		c.Date = RoundDateToDay(c.Date)
+		days[c.Date] = c
+	}
+	for _, c := range t.Completions() {
+		c.Date = RoundDateToDay(c.Date)
+		existing, ok := days[c.Date]
+		if !ok {
+			days[c.Date] = c
+			continue
+		}
+		if existing.Amount != 0 && c.Amount != 0 && existing.Amount != c.Amount {
+			conflicts = append(conflicts, MergeConflict{
+				Habit: o.Name, Day: c.Date, Field: "amount",
+				Ours: formatAmount(existing.Amount), Theirs: formatAmount(c.Amount),
+				Resolved: formatAmount(max(existing.Amount, c.Amount)),
+			})
+		}
+		existing.Amount = max(existing.Amount, c.Amount)
+		if existing.Note != "" && c.Note != "" && existing.Note != c.Note {
+			conflicts = append(conflicts, MergeConflict{
+				Habit: o.Name, Day: c.Date, Field: "note",
+				Ours: existing.Note, Theirs: c.Note,
+				Resolved: preferredNote(existing.Note, c.Note),
+			})
+		}
+		existing.Note = preferredNote(existing.Note, c.Note)
+		existing.Milestone = max(existing.Milestone, c.Milestone)
+		if existing.LoggedAt == nil || c.LoggedAt != nil && c.LoggedAt.Before(*existing.LoggedAt) {
+			existing.LoggedAt = c.LoggedAt
+		}
+		days[c.Date] = existing
+	}
+	o.History = make([]Completion, 0, len(days))
+	for _, c := range days {
+		o.History = append(o.History, c)
+	}
+	sort.Slice(o.History, func(i, j int) bool { return o.History[i].Date.Before(o.History[j].Date) })
+	o.rebuildStreak()
+	return o, conflicts
+}
+
+// mergeAchievements returns the union of achievements sorted
+// by date, keeping the earlier date of achievements in both.
+func mergeAchievements(a, b []Achievement) []Achievement {
+	if len(b) == 0 {
+		return a
+	}
+	byID := make(map[string]Achievement)
+	for _, x := range append(append([]Achievement{}, a...), b...) {
+		if existing, ok := byID[x.ID]; ok && !x.Date.Before(existing.Date) {
+			continue
+		}
+		byID[x.ID] = x
+	}
+	ax := make([]Achievement, 0, len(byID))
+	for _, x := range byID {
+		ax = append(ax, x)
+	}
+	sort.Slice(ax, func(i, j int) bool {
+		if !ax[i].Date.Equal(ax[j].Date) {
+			return ax[i].Date.Before(ax[j].Date)
+		}
+		return ax[i].ID < ax[j].ID
+	})
+	return ax
+}
+
+// mergeChallenges returns the union of challenges sorted by
+// start. Of challenges starting on the same day, the one
+// with more days done is kept, then the one ending later.
+func mergeChallenges(a, b []Challenge) []Challenge {
+	if len(b) == 0 {
+		return a
+	}
+	byStart := make(map[time.Time]Challenge)
+	for _, c := range append(append([]Challenge{}, a...), b...) {
+		if existing, ok := byStart[c.Start]; ok && (existing.Done > c.Done || existing.Done == c.Done && !c.End.After(existing.End)) {
+			continue
+		}
+		byStart[c.Start] = c
+	}
+	cx := make([]Challenge, 0, len(byStart))
+	for _, c := range byStart {
+		cx = append(cx, c)
+	}
+	sort.Slice(cx, func(i, j int) bool { return cx[i].Start.Before(cx[j].Start) })
+	return cx
+}
+
+// String returns the frequency as times per days, for example 3/7.
+func (f Frequency) String() string {
+	return fmt.Sprintf("%d/%d", f.Times, f.Days)
+}
+
+// stricter reports whether the frequency requires more completions
+// per day than the other one. Of equally strict frequencies the
+// one with the shorter period is stricter.
+func (f Frequency) stricter(other Frequency) bool {
+	a, b := f.Times*other.Days, other.Times*f.Days
+	if a != b {
+		return a > b
+	}
+	return f.Days < other.Days
+}
+
+func preferredNote(a, b string) string {
+	switch {
+	case len(a) != len(b):
+		if len(a) > len(b) {
+			return a
+		}
+		return b
+	case a < b:
+		return a
+	}
+	return b
+}
+
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func runMerge(s *FileStore, args []string, wr, ew io.Writer) int {
+	fset := flag.NewFlagSet("merge", flag.ContinueOnError)
+	fset.SetOutput(ew)
+	dryRun := fset.Bool("dry-run", false, "report the result without saving it")
+	args, err := parseArgs(fset, args)
+	if err != nil {
+		return 1
+	}
+	if len(args) != 1 {
+		fmt.Fprint(ew, errors.New("usage: habit merge [--dry-run] other.json"))
+		return 1
+	}
+	// NewFileStore treats a missing file as an empty store,
+	// which would hide a mistyped path.
+	if _, err := os.Stat(args[0]); errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	other, err := NewFileStore(args[0])
+	if err != nil {
+		fmt.Fprintf(ew, "reading %s: %v", args[0], err)
+		return 1
+	}
+	hx, conflicts := Merge(s, other)
+	for _, c := range conflicts {
+		fmt.Fprintln(wr, c)
+	}
+	fmt.Fprintf(wr, "Merged habits: %d, conflicts: %d\n", len(hx), len(conflicts))
+	if *dryRun {
+		return 0
+	}
+	s.Import(hx, ImportReplace)
+	s.AddGlobalAchievements(other.GlobalAchievements())
+	if err := s.Save(); err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package habit_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/habit"
+)
+
+func TestMergeHabits_UnionsCompletionsAndRebuildsStreaks(t *testing.T) {
+	t.Parallel()
+
+	laptop := []habit.Habit{
+		{Name: "jog", Date: day(3).Date, Streak: 2, History: []habit.Completion{day(1), day(2), day(3)}},
+		{Name: "read", Date: day(5).Date, Streak: 1, History: []habit.Completion{day(5)}},
+	}
+	desktop := []habit.Habit{
+		{Name: "jog", Date: day(5).Date, Streak: 1, History: []habit.Completion{day(2), day(4), day(5)}},
+		{Name: "write", Date: day(2).Date, Streak: 1, History: []habit.Completion{day(2)}},
+	}
+	want := []habit.Habit{
+		{Name: "jog", Date: day(5).Date, Streak: 5, History: []habit.Completion{day(1), day(2), day(3), day(4), day(5)}},
+		{Name: "read", Date: day(5).Date, Streak: 1, History: []habit.Completion{day(5)}},
+		{Name: "write", Date: day(2).Date, Streak: 1, History: []habit.Completion{day(2)}},
+	}
+	got, conflicts := habit.MergeHabits(laptop, desktop)
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("want no conflicts, got %v", conflicts)
+	}
+}
+
+func TestMergeHabits_ResolvesConflictsRegardlessOfOrder(t *testing.T) {
+	t.Parallel()
+
+	withAmount := func(c habit.Completion, amount float64, note string) habit.Completion {
+		c.Amount, c.Note = amount, note
+		return c
+	}
+	laptop := []habit.Habit{{
+		Name: "jog", Date: day(2).Date, Streak: 2, Frequency: &habit.Frequency{Times: 3, Days: 7},
+		History: []habit.Completion{withAmount(day(1), 5, "park"), withAmount(day(2), 0, "hills")},
+	}}
+	desktop := []habit.Habit{{
+		Name: "jog", Date: day(2).Date, Streak: 2, Frequency: &habit.Frequency{Times: 4, Days: 7},
+		History: []habit.Completion{withAmount(day(1), 7, "city"), withAmount(day(2), 3, "")},
+	}}
+	want := []habit.Habit{{
+		Name: "jog", Date: day(2).Date, Streak: 2, Frequency: &habit.Frequency{Times: 4, Days: 7},
+		History: []habit.Completion{withAmount(day(1), 7, "city"), withAmount(day(2), 3, "hills")},
+	}}
+	wantConflicts := []string{
+		`habit 'jog': frequency "3/7" differs from "4/7", kept "4/7"`,
+		`habit 'jog' on 2022-10-01: amount "5" differs from "7", kept "7"`,
+		`habit 'jog' on 2022-10-01: note "park" differs from "city", kept "city"`,
+	}
+
+	got, conflicts := habit.MergeHabits(laptop, desktop)
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	var gotConflicts []string
+	for _, c := range conflicts {
+		gotConflicts = append(gotConflicts, c.String())
+	}
+	if !cmp.Equal(wantConflicts, gotConflicts) {
+		t.Error(cmp.Diff(wantConflicts, gotConflicts))
+	}
+
+	got, _ = habit.MergeHabits(desktop, laptop)
+	if !cmp.Equal(want, got) {
+		t.Errorf("want result independent of order, %s", cmp.Diff(want, got))
+	}
+}
+
+func TestMerge_DoesNotModifyStores(t *testing.T) {
+	ours, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ours.Add(habit.Habit{Name: "jog", Date: day(1).Date, Streak: 1, History: []habit.Completion{day(1)}})
+	theirs, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	theirs.Add(habit.Habit{Name: "jog", Date: day(2).Date, Streak: 1, History: []habit.Completion{day(2)}})
+
+	got, _ := habit.Merge(ours, theirs)
+	if len(got) != 1 || got[0].Streak != 2 {
+		t.Errorf("want merged 2-day streak, got %+v", got)
+	}
+	if h, _ := ours.Get("jog"); h.Streak != 1 || len(h.History) != 1 {
+		t.Errorf("want store unchanged, got %+v", h)
+	}
+}
@@ -0,0 +1,120 @@
+package habit
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// metric describes a metric exposed for every habit.
+type metric struct {
+	name  string
+	help  string
+	kind  string
+	value func(Habit, Stats) float64
+}
+
+var metrics = []metric{
+	{
+		name:  "habit_current_streak_days",
+		help:  "Number of consecutive days, up to today or yesterday, the habit was done.",
+		kind:  "gauge",
+		value: func(h Habit, s Stats) float64 { return float64(s.CurrentStreak) },
+	},
+	{
+		name:  "habit_best_streak_days",
+		help:  "Longest number of consecutive days the habit was done.",
+		kind:  "gauge",
+		value: func(h Habit, s Stats) float64 { return float64(s.BestStreak) },
+	},
+	{
+		name:  "habit_days_since_last_completion",
+		help:  "Number of days since the habit was last done.",
+		kind:  "gauge",
+		value: func(h Habit, s Stats) float64 { return float64(DayDiff(h.Date, Now())) },
+	},
+	// Removing days and replacing habits on import lower
+	// the number, so it's a gauge rather than a counter.
+	{
+		name:  "habit_completions",
+		help:  "Number of days the habit was done.",
+		kind:  "gauge",
+		value: func(h Habit, s Stats) float64 { return float64(s.TotalCompletions) },
+	},
+}
+
+// WriteMetrics takes habits and writes metrics about them
+// in the Prometheus text exposition format. Metrics are
+// labelled with the habit name, the only label habits have.
+func WriteMetrics(w io.Writer, hx []Habit) error {
+	stats := make([]Stats, len(hx))
+	for i := range hx {
+		stats[i] = hx[i].Stats()
+	}
+	var sb strings.Builder
+	for _, m := range metrics {
+		fmt.Fprintf(&sb, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(&sb, "# TYPE %s %s\n", m.name, m.kind)
+		for i, h := range hx {
+			fmt.Fprintf(&sb, "%s{habit=\"%s\"} %g\n", m.name, escapeLabel(h.Name), m.value(h, stats[i]))
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// escapeLabel escapes label values as required
+// by the Prometheus text exposition format.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it,
+// so readers like node_exporter never see a partially written file.
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func runMetrics(s Store, args []string, wr, ew io.Writer) int {
+	fset := flag.NewFlagSet("metrics", flag.ContinueOnError)
+	fset.SetOutput(ew)
+	output := fset.String("output", "", "write node_exporter textfile to path instead of standard output")
+	args, err := parseArgs(fset, args)
+	if err != nil {
+		return 1
+	}
+	if len(args) != 0 {
+		fmt.Fprint(ew, errors.New("usage: habit metrics [--output habit.prom]"))
+		return 1
+	}
+	habits := s.GetAll()
+	if *output == "" {
+		err = WriteMetrics(wr, habits)
+	} else {
+		err = writeFileAtomic(*output, func(w io.Writer) error { return WriteMetrics(w, habits) })
+	}
+	if err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	return 0
+}
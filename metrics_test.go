@@ -0,0 +1,68 @@
+package habit_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/habit"
+)
+
+func TestWriteMetrics_WritesGaugesLabelledByHabitName(t *testing.T) {
+	setNow(t, time.Date(2022, 10, 12, 8, 0, 0, 0, time.UTC))
+
+	hx := []habit.Habit{
+		{Name: "jog", Date: day(11).Date, Streak: 2, History: []habit.Completion{day(1), day(2), day(3), day(10), day(11)}},
+		{Name: `say "hi"`, Date: day(5).Date, Streak: 1, History: []habit.Completion{day(5)}},
+	}
+
+	var buf bytes.Buffer
+	if err := habit.WriteMetrics(&buf, hx); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP habit_current_streak_days Number of consecutive days, up to today or yesterday, the habit was done.
+# TYPE habit_current_streak_days gauge
+habit_current_streak_days{habit="jog"} 2
+habit_current_streak_days{habit="say \"hi\""} 0
+# HELP habit_best_streak_days Longest number of consecutive days the habit was done.
+# TYPE habit_best_streak_days gauge
+habit_best_streak_days{habit="jog"} 3
+habit_best_streak_days{habit="say \"hi\""} 1
+# HELP habit_days_since_last_completion Number of days since the habit was last done.
+# TYPE habit_days_since_last_completion gauge
+habit_days_since_last_completion{habit="jog"} 1
+habit_days_since_last_completion{habit="say \"hi\""} 7
+# HELP habit_completions Number of days the habit was done.
+# TYPE habit_completions gauge
+habit_completions{habit="jog"} 5
+habit_completions{habit="say \"hi\""} 1
+`
+	got := buf.String()
+	if want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestServer_ExposesMetrics(t *testing.T) {
+	setNow(t, time.Date(2022, 10, 12, 8, 0, 0, 0, time.UTC))
+	ts, store := newTestServer(t)
+	store.Add(habit.Habit{Name: "jog", Date: day(12).Date, Streak: 1, History: []habit.Completion{day(12)}})
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `habit_current_streak_days{habit="jog"} 1`
+	if !strings.Contains(string(data), want) {
+		t.Errorf("want metrics containing %q, got %q", want, data)
+	}
+}
@@ -0,0 +1,219 @@
+package habit
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMilestones holds streak lengths, in days, celebrated
+// as milestones of habits without their own milestones.
+var DefaultMilestones = []int{7, 21, 30, 66, 100, 365}
+
+// Milestone represents a milestone reached by a habit.
+type Milestone struct {
+	Habit  string    `json:"habit"`
+	Streak int       `json:"streak"`
+	Date   time.Time `json:"date"`
+}
+
+// milestones returns streak lengths celebrated
+// as milestones of the habit.
+func (h *Habit) milestones() []int {
+	if h.Milestones == nil {
+		return DefaultMilestones
+	}
+	return h.Milestones
+}
+
+func (h *Habit) isMilestone(streak int) bool {
+	for _, m := range h.milestones() {
+		if m == streak {
+			return true
+		}
+	}
+	return false
+}
+
+// markMilestones takes days the habit was done, oldest first, and
+// marks completions on which a streak grew to the length of one
+// of the habit's milestones, clearing marks of other completions.
+func (h *Habit) markMilestones(days []time.Time) {
+	gap := h.Frequency.gap()
+	streakOn := make(map[time.Time]int, len(days))
+	for i, d := range days {
+		streakOn[d] = 1
+		if i > 0 && DayDiff(days[i-1], d) <= gap {
+			streakOn[d] = streakOn[days[i-1]] + 1
+		}
+	}
+	for i, c := range h.History {
+		h.History[i].Milestone = 0
+		if streak := streakOn[RoundDateToDay(c.Date)]; h.isMilestone(streak) {
+			h.History[i].Milestone = streak
+		}
+	}
+}
+
+// MilestonesReached returns milestones the habit reached,
+// oldest first. A milestone is reached again by every
+// streak growing to its length.
+func (h *Habit) MilestonesReached() []Milestone {
+	var mx []Milestone
+	for _, c := range h.Completions() {
+		if c.Milestone != 0 {
+			mx = append(mx, Milestone{Habit: h.Name, Streak: c.Milestone, Date: RoundDateToDay(c.Date)})
+		}
+	}
+	return mx
+}
+
+// NextMilestone returns the length of the next milestone
+// of the current streak. It returns false if the streak
+// is longer than all of the habit's milestones.
+func (h *Habit) NextMilestone() (int, bool) {
+	streak := h.Stats().CurrentStreak
+	for _, m := range normalizeMilestones(h.milestones()) {
+		if m > streak {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+// ParseMilestones takes a comma separated list of streak lengths,
+// like "7,30,100", and returns them sorted. It returns an error
+// if a length is not a number greater than 1, as every streak
+// starts with a day.
+func ParseMilestones(s string) ([]int, error) {
+	var mx []int
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		m, err := strconv.Atoi(field)
+		if err != nil || m < 2 {
+			return nil, fmt.Errorf("invalid milestone %q, want a number of days greater than 1", field)
+		}
+		mx = append(mx, m)
+	}
+	if len(mx) == 0 {
+		return nil, errors.New("no milestones given")
+	}
+	return normalizeMilestones(mx), nil
+}
+
+// normalizeMilestones returns milestones sorted, without duplicates.
+func normalizeMilestones(mx []int) []int {
+	sorted := append([]int{}, mx...)
+	sort.Ints(sorted)
+	unique := sorted[:0]
+	for i, m := range sorted {
+		if i > 0 && m == sorted[i-1] {
+			continue
+		}
+		unique = append(unique, m)
+	}
+	return unique
+}
+
+func formatMilestones(mx []int) string {
+	sx := make([]string, len(mx))
+	for i, m := range mx {
+		sx[i] = strconv.Itoa(m)
+	}
+	return strings.Join(sx, ",")
+}
+
+// SetMilestones takes a habit name and streak lengths and sets
+// them as the habit's milestones. Nil milestones restore
+// DefaultMilestones. It returns an error if the habit
+// is not tracked.
+//
+// SetMilestones does not persist data in the store. After
+// calling SetMilestones(), call Save() to persist data.
+func (f *FileStore) SetMilestones(habitName string, mx []int) error {
+	h, ok := f.Get(habitName)
+	if !ok {
+		return fmt.Errorf("habit '%s' is not tracked", habitName)
+	}
+	h.Milestones = mx
+	f.Add(h)
+	return nil
+}
+
+func runMilestones(s Store, args []string, wr, ew io.Writer) int {
+	fset := flag.NewFlagSet("milestones", flag.ContinueOnError)
+	fset.SetOutput(ew)
+	set := fset.String("set", "", `set milestones of the habit, for example "7,30,100", or "default"`)
+	args, err := parseArgs(fset, args)
+	if err != nil {
+		return 1
+	}
+	if len(args) > 1 || *set != "" && len(args) != 1 {
+		fmt.Fprint(ew, errors.New("usage: habit milestones [name] [--set 7,30,100|default]"))
+		return 1
+	}
+	name := ""
+	if len(args) == 1 {
+		name = args[0]
+	}
+
+	if *set != "" {
+		fstore, ok := s.(*FileStore)
+		if !ok {
+			fmt.Fprint(ew, "setting milestones works only with a local file store")
+			return 1
+		}
+		var mx []int
+		if *set != "default" {
+			mx, err = ParseMilestones(*set)
+			if err != nil {
+				fmt.Fprint(ew, err)
+				return 1
+			}
+		}
+		if err := fstore.SetMilestones(name, mx); err != nil {
+			fmt.Fprint(ew, err)
+			return 1
+		}
+		if err := fstore.Save(); err != nil {
+			fmt.Fprint(ew, err)
+			return 1
+		}
+		h, _ := fstore.Get(name)
+		fmt.Fprintf(wr, "Milestones of '%s': %s days\n", name, formatMilestones(h.milestones()))
+		return 0
+	}
+
+	hx, err := selectHabits(s, name)
+	if err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	var mx []Milestone
+	for _, h := range hx {
+		mx = append(mx, h.MilestonesReached()...)
+	}
+	sort.SliceStable(mx, func(i, j int) bool { return mx[i].Date.Before(mx[j].Date) })
+	if len(mx) == 0 {
+		fmt.Fprintln(wr, "No milestones reached yet.")
+	}
+	for _, m := range mx {
+		fmt.Fprintf(wr, "%s  %s: %d days\n", m.Date.Format(dateLayout), m.Habit, m.Streak)
+	}
+	if name == "" {
+		return 0
+	}
+	h := hx[0]
+	if next, ok := h.NextMilestone(); ok {
+		fmt.Fprintf(wr, "Next milestone of '%s': %d days, %d to go\n", h.Name, next, next-h.Stats().CurrentStreak)
+	}
+	return 0
+}
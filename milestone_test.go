@@ -0,0 +1,139 @@
+package habit_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/habit"
+)
+
+func TestRecordEventOn_ReportsMilestoneAndMarksCompletion(t *testing.T) {
+	t.Parallel()
+
+	h := habit.Habit{Name: "jog", Date: day(6).Date, Streak: 6}
+	e := h.RecordEventOn(day(7).Date)
+	want := habit.Event{
+		Kind:    habit.EventMilestone,
+		Habit:   "jog",
+		Streak:  7,
+		Message: "Milestone reached: you've done the habit 'jog' for 7 days in a row! Take a moment to celebrate.\n",
+	}
+	if !cmp.Equal(want, e) {
+		t.Error(cmp.Diff(want, e))
+	}
+
+	e = h.RecordEventOn(day(8).Date)
+	if e.Kind != habit.EventContinued {
+		t.Errorf("want continued event after milestone, got %q", e.Kind)
+	}
+
+	wantReached := []habit.Milestone{{Habit: "jog", Streak: 7, Date: day(7).Date}}
+	if got := h.MilestonesReached(); !cmp.Equal(wantReached, got) {
+		t.Error(cmp.Diff(wantReached, got))
+	}
+}
+
+func TestRecordEventOn_UsesMilestonesOfHabit(t *testing.T) {
+	t.Parallel()
+
+	h := habit.Habit{Name: "jog", Date: day(1).Date, Streak: 1, Milestones: []int{2, 10}}
+	if e := h.RecordEventOn(day(2).Date); e.Kind != habit.EventMilestone {
+		t.Errorf("want milestone on day 2, got %q", e.Kind)
+	}
+	h = habit.Habit{Name: "jog", Date: day(6).Date, Streak: 6, Milestones: []int{2, 10}}
+	if e := h.RecordEventOn(day(7).Date); e.Kind != habit.EventContinued {
+		t.Errorf("want no default milestone on day 7, got %q", e.Kind)
+	}
+}
+
+func TestRecordEventOn_ReportsKindOfEvent(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		day  int
+		want habit.EventKind
+	}{
+		{day: 10, want: habit.EventNone},
+		{day: 11, want: habit.EventContinued},
+		{day: 13, want: habit.EventRestarted},
+		{day: 8, want: habit.EventBackfilled},
+	}
+	for _, tc := range tt {
+		h := habit.Habit{Name: "jog", Date: day(10).Date, Streak: 1}
+		if got := h.RecordEventOn(day(tc.day).Date); tc.want != got.Kind {
+			t.Errorf("day %d: want %q, got %q", tc.day, tc.want, got.Kind)
+		}
+	}
+}
+
+func TestRecordEventOn_MarksMilestoneReachedByBackfilledDay(t *testing.T) {
+	t.Parallel()
+
+	h := habit.Habit{Name: "jog", Milestones: []int{3, 5}}
+	for _, d := range []int{1, 2, 4, 5} {
+		h.RecordEventOn(day(d).Date)
+	}
+	if got := h.MilestonesReached(); len(got) != 0 {
+		t.Fatalf("want no milestones before backfilling, got %v", got)
+	}
+	h.RecordEventOn(day(3).Date)
+	want := []habit.Milestone{
+		{Habit: "jog", Streak: 3, Date: day(3).Date},
+		{Habit: "jog", Streak: 5, Date: day(5).Date},
+	}
+	if got := h.MilestonesReached(); !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+
+	if err := h.Unrecord(day(3).Date); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.MilestonesReached(); len(got) != 0 {
+		t.Errorf("want no milestones after removing the day, got %v", got)
+	}
+}
+
+func TestRecordEventOn_ReportsMilestoneOfHabitWithFrequencyOverRestDays(t *testing.T) {
+	t.Parallel()
+
+	h := habit.Habit{Name: "gym", Milestones: []int{3}, Frequency: &habit.Frequency{Times: 3, Days: 7}}
+	h.RecordEventOn(day(1).Date)
+	h.RecordEventOn(day(3).Date)
+	if e := h.RecordEventOn(day(6).Date); e.Kind != habit.EventMilestone || e.Streak != 3 {
+		t.Errorf("want milestone of 3-day streak, got %q with streak %d", e.Kind, e.Streak)
+	}
+	want := []habit.Milestone{{Habit: "gym", Streak: 3, Date: day(6).Date}}
+	if got := h.MilestonesReached(); !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestNextMilestone_ReturnsNextMilestoneOfCurrentStreak(t *testing.T) {
+	setNow(t, day(10).Date)
+
+	h := habit.Habit{Name: "jog", Date: day(10).Date, Streak: 8}
+	if got, ok := h.NextMilestone(); !ok || got != 21 {
+		t.Errorf("want 21, got %d, %t", got, ok)
+	}
+	h = habit.Habit{Name: "jog", Date: day(10).Date, Streak: 8, Milestones: []int{3, 5}}
+	if _, ok := h.NextMilestone(); ok {
+		t.Error("want no next milestone")
+	}
+}
+
+func TestParseMilestones(t *testing.T) {
+	t.Parallel()
+
+	got, err := habit.ParseMilestones("30, 7,30,100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{7, 30, 100}; !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	for _, s := range []string{"", "7,x", "0", "1", "-7"} {
+		if _, err := habit.ParseMilestones(s); err == nil {
+			t.Errorf("%q: want error, got nil", s)
+		}
+	}
+}
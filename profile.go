@@ -0,0 +1,102 @@
+package habit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultProfile is the name of the profile
+// using the default file store.
+const defaultProfile = "default"
+
+// storeLocation returns the path, or the server URL, of the store
+// selected by the --store or --profile flag, or by the HABIT_STORE
+// environment variable, in order of precedence. Without any of
+// them it returns path to the default file store.
+func storeLocation(store, profile string) (string, error) {
+	switch {
+	case store != "" && profile != "":
+		return "", errors.New("flags --store and --profile can't be used together")
+	case store != "":
+		return store, nil
+	case profile != "":
+		return profilePath(profile)
+	case os.Getenv("HABIT_STORE") != "":
+		return os.Getenv("HABIT_STORE"), nil
+	}
+	return fileStorePath(), nil
+}
+
+// isURL reports whether the store location is a URL of a habit server.
+func isURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// profilesDir returns path to the directory with stores of profiles.
+func profilesDir() string {
+	return filepath.Join(dataDir(), ".habits-profiles")
+}
+
+// profilePath takes a profile name and returns path to its store.
+// It returns an error if the name is not a valid file name.
+func profilePath(name string) (string, error) {
+	if name == defaultProfile {
+		return fileStorePath(), nil
+	}
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid profile name: %q", name)
+	}
+	return filepath.Join(profilesDir(), name+".json"), nil
+}
+
+// profiles returns names of profiles, the default one first
+// and the others, which have their stores created, sorted.
+func profiles() ([]string, error) {
+	names := []string{defaultProfile}
+	entries, err := os.ReadDir(profilesDir())
+	if errors.Is(err, fs.ErrNotExist) {
+		return names, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var others []string
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if e.IsDir() || !ok || name == defaultProfile {
+			continue
+		}
+		others = append(others, name)
+	}
+	sort.Strings(others)
+	return append(names, others...), nil
+}
+
+// runProfiles lists profiles, marking the one in use
+// with an asterisk. The store location is compared with
+// paths of profiles' stores to find the profile in use.
+func runProfiles(location string, args []string, wr, ew io.Writer) int {
+	if len(args) != 0 {
+		fmt.Fprint(ew, errors.New("usage: habit profiles"))
+		return 1
+	}
+	names, err := profiles()
+	if err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	for _, name := range names {
+		mark := " "
+		if path, _ := profilePath(name); path == location {
+			mark = "*"
+		}
+		fmt.Fprintf(wr, "%s %s\n", mark, name)
+	}
+	return 0
+}
@@ -0,0 +1,393 @@
+package habit
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Report holds how habits were done over a week, a month or a year.
+type Report struct {
+	Period      string // Period holds the kind of the period: week, month or year.
+	Title       string
+	Start       time.Time
+	End         time.Time // End holds the last day of the period.
+	Completions int
+	Habits      []HabitReport
+}
+
+// HabitReport holds how a habit was done over the period of a report.
+type HabitReport struct {
+	Name         string
+	Completions  int
+	Days         int     // Days holds the number of days of the period the habit was tracked on, up to today.
+	Rate         float64 // Rate holds the ratio of completions to Days.
+	StreakBefore int     // StreakBefore holds the streak going into the period.
+	StreakAfter  int     // StreakAfter holds the streak on the last day of the period, or today.
+	BestStreak   int     // BestStreak holds the longest run of days done within the period.
+	BestDay      string  // BestDay holds the weekday the habit was done on most often, if it was done.
+	Calendar     ReportCalendar
+}
+
+// ReportCalendar holds a small calendar of days of the period:
+// a row per week for weeks and months, a row per month for years.
+type ReportCalendar struct {
+	Header []string
+	Rows   []ReportRow
+}
+
+// ReportRow holds days of a row of the calendar of a report.
+type ReportRow struct {
+	Label string
+	Cells []ReportCell
+}
+
+// ReportCell holds a day of the calendar of a report.
+type ReportCell struct {
+	Day   time.Time
+	State string // State is "done", "missed", or empty for days out of the period, before tracking or after today.
+}
+
+// reportPeriods holds kinds of periods reports cover.
+var reportPeriods = []string{"week", "month", "year"}
+
+// periodOf returns the first and the last day of the period of the
+// given kind holding the day. Weeks start on Monday.
+func periodOf(period string, day time.Time) (time.Time, time.Time, error) {
+	day = RoundDateToDay(day)
+	switch period {
+	case "week":
+		start := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 6), nil
+	case "month":
+		start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, -1), nil
+	case "year":
+		start := time.Date(day.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, -1), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("unsupported report period: %s, want one of: %s", period, strings.Join(reportPeriods, ", "))
+}
+
+// NewReport takes habits, the kind of the period, week, month or year,
+// and a day, and returns the report of the period holding the day.
+//
+// Reports of past periods depend only on completions, so they
+// are the same whenever they're made. Reports of the period going
+// on cover days up to today. It returns an error if the period
+// starts after today.
+func NewReport(hx []Habit, period string, day time.Time) (Report, error) {
+	start, end, err := periodOf(period, day)
+	if err != nil {
+		return Report{}, err
+	}
+	today := RoundDateToDay(Now())
+	if start.After(today) {
+		return Report{}, fmt.Errorf("report period starts on %s, after today", start.Format(dateLayout))
+	}
+	r := Report{Period: period, Title: periodTitle(period, start, end), Start: start, End: end}
+	last := end
+	if today.Before(last) {
+		last = today
+	}
+	for _, h := range hx {
+		days := h.Days()
+		if len(days) == 0 || days[0].After(end) {
+			continue
+		}
+		hr := habitReport(h.Name, days, h.Frequency.gap(), start, last, period)
+		r.Completions += hr.Completions
+		r.Habits = append(r.Habits, hr)
+	}
+	return r, nil
+}
+
+func periodTitle(period string, start, end time.Time) string {
+	switch period {
+	case "week":
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("Week %d of %d, %s to %s", week, year, start.Format(dateLayout), end.Format(dateLayout))
+	case "month":
+		return start.Format("January 2006")
+	}
+	return strconv.Itoa(start.Year())
+}
+
+// habitReport returns the report of a habit done on the days,
+// over the period from start to the last day counted. Days in
+// a streak are at most gap days apart.
+func habitReport(name string, days []time.Time, gap int, start, last time.Time, period string) HabitReport {
+	hr := HabitReport{Name: name}
+	done := make(map[time.Time]bool)
+	for _, d := range days {
+		done[d] = true
+	}
+	from := start
+	if days[0].After(from) {
+		from = days[0]
+	}
+	var weekdays [7]int
+	run := 0
+	var prev time.Time
+	for d := from; !d.After(last); d = d.AddDate(0, 0, 1) {
+		hr.Days++
+		if !done[d] {
+			continue
+		}
+		hr.Completions++
+		weekdays[d.Weekday()]++
+		if prev.IsZero() || DayDiff(prev, d) > gap {
+			run = 0
+		}
+		run++
+		prev = d
+		hr.BestStreak = max(hr.BestStreak, run)
+	}
+	if hr.Days > 0 {
+		hr.Rate = float64(hr.Completions) / float64(hr.Days)
+	}
+	hr.StreakBefore = streakAt(done, gap, start.AddDate(0, 0, -1))
+	hr.StreakAfter = streakAt(done, gap, last)
+	best := 0
+	// Weekdays are compared from Monday on.
+	for i := 1; i <= 7; i++ {
+		wd := time.Weekday(i % 7)
+		if weekdays[wd] > best {
+			best, hr.BestDay = weekdays[wd], wd.String()
+		}
+	}
+	hr.Calendar = reportCalendar(done, from, start, last, period)
+	return hr
+}
+
+// streakAt returns the length of the streak on the day: the run of
+// days done, at most gap days apart, ending on the last day done
+// no more than gap days before the day.
+func streakAt(done map[time.Time]bool, gap int, day time.Time) int {
+	n := 0
+	// The first day may be the day itself, every next
+	// one is searched before the day found last.
+	for first := 0; ; first = 1 {
+		i := first
+		for i <= gap && !done[day.AddDate(0, 0, -i)] {
+			i++
+		}
+		if i > gap {
+			return n
+		}
+		day = day.AddDate(0, 0, -i)
+		n++
+	}
+}
+
+// reportCalendar returns the calendar of the period starting on
+// start. Days before from and after last are left empty, and
+// rows without other days are skipped.
+func reportCalendar(done map[time.Time]bool, from, start, last time.Time, period string) ReportCalendar {
+	_, end, _ := periodOf(period, start)
+	cell := func(d time.Time) ReportCell {
+		switch {
+		case d.Before(from) || d.After(last):
+			return ReportCell{Day: d}
+		case done[d]:
+			return ReportCell{Day: d, State: "done"}
+		}
+		return ReportCell{Day: d, State: "missed"}
+	}
+	var c ReportCalendar
+	if period == "year" {
+		for i := 1; i <= 31; i++ {
+			c.Header = append(c.Header, strconv.Itoa(i))
+		}
+		for m := start; !m.After(end); m = m.AddDate(0, 1, 0) {
+			row := ReportRow{Label: m.Format("Jan")}
+			for d := m; d.Month() == m.Month(); d = d.AddDate(0, 0, 1) {
+				row.Cells = append(row.Cells, cell(d))
+			}
+			c.addRow(row)
+		}
+		return c
+	}
+	c.Header = []string{"Mo", "Tu", "We", "Th", "Fr", "Sa", "Su"}
+	monday := start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+	for w := monday; !w.After(end); w = w.AddDate(0, 0, 7) {
+		row := ReportRow{Label: w.Format("Jan 02")}
+		for i := 0; i < 7; i++ {
+			row.Cells = append(row.Cells, cell(w.AddDate(0, 0, i)))
+		}
+		c.addRow(row)
+	}
+	return c
+}
+
+func (c *ReportCalendar) addRow(row ReportRow) {
+	for _, cell := range row.Cells {
+		if cell.State != "" {
+			c.Rows = append(c.Rows, row)
+			return
+		}
+	}
+}
+
+var reportFuncs = template.FuncMap{
+	"percent": func(v float64) string { return strconv.FormatFloat(v*100, 'f', 0, 64) + "%" },
+	"date":    func(t time.Time) string { return t.Format(dateLayout) },
+	"mark": func(c ReportCell) string {
+		switch c.State {
+		case "done":
+			return "✔"
+		case "missed":
+			return "·"
+		}
+		return " "
+	},
+	"pad": func(s string, n int) string { return fmt.Sprintf("%-*s", n, s) },
+	// days returns the number of days with the noun in singular
+	// or plural. Reports are written in English only.
+	"days": func(n int) string {
+		if n == 1 {
+			return "1 day"
+		}
+		return fmt.Sprintf("%d days", n)
+	},
+}
+
+var markdownReport = template.Must(template.New("md").Funcs(reportFuncs).Parse(`# Habit report: {{.Title}}
+
+{{if not .Habits}}No habits tracked in this period.
+{{else}}Completions of all habits: {{.Completions}}
+{{range .Habits}}
+## {{.Name}}
+
+- Completions: {{.Completions}} of {{days .Days}} ({{percent .Rate}})
+- Streak: {{.StreakBefore}} → {{days .StreakAfter}}, best in the period {{days .BestStreak}}
+- Best day: {{if .BestDay}}{{.BestDay}}{{else}}none{{end}}
+
+` + "```" + `
+{{pad "" 6}} {{range .Calendar.Header}}{{pad . 2}} {{end}}
+{{range .Calendar.Rows}}{{pad .Label 6}} {{range .Cells}}{{pad (mark .) 2}} {{end}}
+{{end}}` + "```" + `
+{{end}}{{end}}`))
+
+var htmlReport = htmltemplate.Must(htmltemplate.New("html").Funcs(htmltemplate.FuncMap(reportFuncs)).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Habit report: {{.Title}}</title>
+<style>
+body { font-family: sans-serif; }
+table.calendar td { width: 1.5em; height: 1.5em; text-align: center; }
+td.done { background: #40c463; }
+td.missed { background: #ebedf0; }
+</style>
+</head>
+<body>
+<h1>Habit report: {{.Title}}</h1>
+{{if not .Habits}}<p>No habits tracked in this period.</p>
+{{else}}<p>Completions of all habits: {{.Completions}}</p>
+{{range .Habits}}<h2>{{.Name}}</h2>
+<ul>
+<li>Completions: {{.Completions}} of {{days .Days}} ({{percent .Rate}})</li>
+<li>Streak: {{.StreakBefore}} → {{days .StreakAfter}}, best in the period {{days .BestStreak}}</li>
+<li>Best day: {{if .BestDay}}{{.BestDay}}{{else}}none{{end}}</li>
+</ul>
+<table class="calendar">
+<tr><th></th>{{range .Calendar.Header}}<th>{{.}}</th>{{end}}</tr>
+{{range .Calendar.Rows}}<tr><th>{{.Label}}</th>{{range .Cells}}<td{{if .State}} class="{{.State}}" title="{{date .Day}}"{{end}}>{{mark .}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}{{end}}</body>
+</html>
+`))
+
+// WriteReportMarkdown writes the report in Markdown format.
+func WriteReportMarkdown(w io.Writer, r Report) error {
+	var sb strings.Builder
+	if err := markdownReport.Execute(&sb, r); err != nil {
+		return err
+	}
+	// Trailing spaces of calendar rows would turn
+	// into line breaks in some Markdown renderers.
+	lines := strings.Split(sb.String(), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " ")
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// WriteReportHTML writes the report as an HTML page.
+func WriteReportHTML(w io.Writer, r Report) error {
+	return htmlReport.Execute(w, r)
+}
+
+func runReport(s Store, args []string, wr, ew io.Writer) int {
+	fset := flag.NewFlagSet("report", flag.ContinueOnError)
+	fset.SetOutput(ew)
+	period := fset.String("period", "week", "period of the report: week, month or year")
+	format := fset.String("format", "md", "report format: md or html")
+	date := fset.String("date", "", "a day of the period to report, as YYYY-MM-DD, today by default")
+	output := fset.String("output", "", "write to file instead of standard output")
+	args, err := parseArgs(fset, args)
+	if err != nil {
+		return 1
+	}
+	if len(args) > 1 {
+		fmt.Fprint(ew, errors.New("usage: habit report [name] [--period week|month|year] [--format md|html] [--date YYYY-MM-DD] [--output file]"))
+		return 1
+	}
+	var write func(io.Writer, Report) error
+	switch *format {
+	case "md":
+		write = WriteReportMarkdown
+	case "html":
+		write = WriteReportHTML
+	default:
+		fmt.Fprintf(ew, "unsupported report format: %s", *format)
+		return 1
+	}
+	day := Now()
+	if *date != "" {
+		day, err = time.Parse(dateLayout, *date)
+		if err != nil {
+			fmt.Fprintf(ew, "invalid date %q, want YYYY-MM-DD", *date)
+			return 1
+		}
+	}
+	name := ""
+	if len(args) == 1 {
+		name = args[0]
+	}
+	hx, err := selectHabits(s, name)
+	if err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	r, err := NewReport(hx, *period, day)
+	if err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+
+	w := wr
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprint(ew, err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := write(w, r); err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	return 0
+}
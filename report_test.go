@@ -0,0 +1,155 @@
+package habit_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/habit"
+)
+
+func reportHabits() []habit.Habit {
+	return []habit.Habit{
+		{Name: "jog", History: []habit.Completion{day(1), day(2), day(3), day(4), day(6), day(7), day(8)}},
+		{Name: "read", History: []habit.Completion{day(5), day(6)}},
+		{Name: "write", History: []habit.Completion{day(20)}},
+	}
+}
+
+func TestWriteReportMarkdown_ReportsWeekOfHabits(t *testing.T) {
+	setNow(t, day(20).Date)
+
+	r, err := habit.NewReport(reportHabits(), "week", day(5).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := habit.WriteReportMarkdown(&sb, r); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Habit report: Week 40 of 2022, 2022-10-03 to 2022-10-09\n" +
+		"\n" +
+		"Completions of all habits: 7\n" +
+		"\n" +
+		"## jog\n" +
+		"\n" +
+		"- Completions: 5 of 7 days (71%)\n" +
+		"- Streak: 2 → 3 days, best in the period 3 days\n" +
+		"- Best day: Monday\n" +
+		"\n" +
+		"```\n" +
+		"       Mo Tu We Th Fr Sa Su\n" +
+		"Oct 03 ✔  ✔  ·  ✔  ✔  ✔  ·\n" +
+		"```\n" +
+		"\n" +
+		"## read\n" +
+		"\n" +
+		"- Completions: 2 of 5 days (40%)\n" +
+		"- Streak: 0 → 0 days, best in the period 2 days\n" +
+		"- Best day: Wednesday\n" +
+		"\n" +
+		"```\n" +
+		"       Mo Tu We Th Fr Sa Su\n" +
+		"Oct 03       ✔  ✔  ·  ·  ·\n" +
+		"```\n"
+	if got := sb.String(); want != got {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestWriteReportMarkdown_WritesSingularDayInEnglishWhateverLanguageOfMessages(t *testing.T) {
+	setNow(t, day(20).Date)
+	setLanguage(t, "pl")
+
+	r, err := habit.NewReport([]habit.Habit{{Name: "write", History: []habit.Completion{day(20)}}}, "week", day(20).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := habit.WriteReportMarkdown(&sb, r); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"- Completions: 1 of 1 day (100%)\n", "- Streak: 0 → 1 day, best in the period 1 day\n"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("want report containing %q, got:\n%s", want, sb.String())
+		}
+	}
+}
+
+func TestNewReport_IsReproducibleForPastPeriod(t *testing.T) {
+	setNow(t, day(10).Date)
+	before, err := habit.NewReport(reportHabits(), "month", day(3).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setNow(t, time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC))
+	hx := reportHabits()
+	hx[0].History = append(hx[0].History, habit.Completion{Date: time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)})
+	after, err := habit.NewReport(hx, "month", day(28).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.Habits[0].Days != 10 || after.Habits[0].Days != 31 {
+		t.Errorf("want 10 days reported so far and 31 days of the whole month, got %d and %d", before.Habits[0].Days, after.Habits[0].Days)
+	}
+
+	setNow(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	again, err := habit.NewReport(hx, "month", day(1).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(after, again) {
+		t.Error(cmp.Diff(after, again))
+	}
+}
+
+func TestNewReport_KeepsStreaksOfHabitWithFrequencyOverRestDays(t *testing.T) {
+	setNow(t, day(20).Date)
+
+	hx := []habit.Habit{{
+		Name:      "gym",
+		History:   []habit.Completion{day(1), day(3), day(5), day(8)},
+		Frequency: &habit.Frequency{Times: 3, Days: 7},
+	}}
+	r, err := habit.NewReport(hx, "week", day(5).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := r.Habits[0]
+	if got.StreakBefore != 1 || got.StreakAfter != 4 || got.BestStreak != 3 {
+		t.Errorf("want streak 1 → 4, best in the period 3, got %d → %d, best %d", got.StreakBefore, got.StreakAfter, got.BestStreak)
+	}
+}
+
+func TestNewReport_ErrorsOnInvalidPeriod(t *testing.T) {
+	setNow(t, day(10).Date)
+
+	if _, err := habit.NewReport(nil, "fortnight", day(1).Date); err == nil {
+		t.Error("want error on unsupported period")
+	}
+	if _, err := habit.NewReport(nil, "week", day(20).Date); err == nil {
+		t.Error("want error on period starting after today")
+	}
+}
+
+func TestWriteReportHTML_EscapesHabitNames(t *testing.T) {
+	setNow(t, day(10).Date)
+
+	hx := []habit.Habit{{Name: "<b>jog</b>", History: []habit.Completion{day(1)}}}
+	r, err := habit.NewReport(hx, "year", day(1).Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := habit.WriteReportHTML(&sb, r); err != nil {
+		t.Fatal(err)
+	}
+	got := sb.String()
+	for _, want := range []string{"<title>Habit report: 2022</title>", "<h2>&lt;b&gt;jog&lt;/b&gt;</h2>", `<td class="done" title="2022-10-01">✔</td>`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want HTML containing %q, got:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package habit
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed openapi.yaml
+var openAPI []byte
+
+// dashboard holds the web dashboard served at the root path.
+// It has no external assets, so it works offline.
+//
+//go:embed web
+var dashboard embed.FS
+
+// Server exposes habits kept in a file store over a JSON REST API.
+//
+// Requests modifying the store are serialized and hold the store's
+// lock, so neither concurrent clients nor the command line run
+// meanwhile overwrite each other's logs. Habits are reloaded when
+// the file changes, so the server serves logs made by the command line.
+//
+// Requests modifying the store from web pages of other origins are
+// rejected, so visited sites can't log or delete habits.
+type Server struct {
+	store *FileStore
+	mu    sync.Mutex
+}
+
+// NewServer takes a file store and returns a server
+// exposing the store over HTTP.
+func NewServer(store *FileStore) *Server {
+	return &Server{store: store}
+}
+
+// ServeHTTP routes requests to the API endpoints:
+//
+//	GET    /habits              lists habits
+//	GET    /habits/{name}       returns the habit
+//	DELETE /habits/{name}       stops tracking the habit
+//	POST   /habits/{name}/log   records the habit activity, optionally on {"date": ...}
+//	GET    /habits/{name}/stats returns statistics of the habit
+//	GET    /stats               returns statistics of all habits
+//	GET    /achievements        lists achievements unlocked once for all habits
+//	GET    /check               reports about all habits
+//	GET    /metrics             returns metrics in Prometheus format
+//	GET    /openapi.yaml        returns OpenAPI description of the API
+//
+// Other GET requests are served by the web dashboard.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts, err := pathSegments(r.URL)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && !sameOrigin(r) {
+		writeError(w, http.StatusForbidden, errors.New("cross-origin requests are not allowed"))
+		return
+	}
+	if r.Method == http.MethodGet {
+		if err := s.reload(); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	switch {
+	case len(parts) == 0 || parts[0] == "assets":
+		s.route(w, r, map[string]http.HandlerFunc{http.MethodGet: serveDashboard})
+	case len(parts) == 1 && parts[0] == "habits":
+		s.route(w, r, map[string]http.HandlerFunc{http.MethodGet: s.listHabits})
+	case len(parts) == 1 && parts[0] == "stats":
+		s.route(w, r, map[string]http.HandlerFunc{http.MethodGet: s.listStats})
+	case len(parts) == 1 && parts[0] == "achievements":
+		s.route(w, r, map[string]http.HandlerFunc{http.MethodGet: s.listAchievements})
+	case len(parts) == 1 && parts[0] == "check":
+		s.route(w, r, map[string]http.HandlerFunc{http.MethodGet: s.check})
+	case len(parts) == 1 && parts[0] == "metrics":
+		s.route(w, r, map[string]http.HandlerFunc{http.MethodGet: s.metrics})
+	case len(parts) == 1 && parts[0] == "openapi.yaml":
+		s.route(w, r, map[string]http.HandlerFunc{http.MethodGet: serveOpenAPI})
+	case len(parts) == 2 && parts[0] == "habits":
+		s.route(w, r, map[string]http.HandlerFunc{
+			http.MethodGet:    func(w http.ResponseWriter, r *http.Request) { s.getHabit(w, parts[1]) },
+			http.MethodDelete: func(w http.ResponseWriter, r *http.Request) { s.deleteHabit(w, parts[1]) },
+		})
+	case len(parts) == 3 && parts[0] == "habits" && parts[2] == "log":
+		s.route(w, r, map[string]http.HandlerFunc{
+			http.MethodPost: func(w http.ResponseWriter, r *http.Request) { s.logHabit(w, r, parts[1]) },
+		})
+	case len(parts) == 3 && parts[0] == "habits" && parts[2] == "stats":
+		s.route(w, r, map[string]http.HandlerFunc{
+			http.MethodGet: func(w http.ResponseWriter, r *http.Request) { s.getStats(w, parts[1]) },
+		})
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+// sameOrigin reports whether the request doesn't come from a web
+// page of another origin. Browsers send the Origin header with every
+// cross-origin POST and DELETE, clients like curl don't send it.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// reload reads habits changed by other processes.
+func (s *Server) reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.store.Lock(); err != nil {
+		return err
+	}
+	return s.store.Unlock()
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request, handlers map[string]http.HandlerFunc) {
+	h, ok := handlers[r.Method]
+	if !ok {
+		methods := make([]string, 0, len(handlers))
+		for m := range handlers {
+			methods = append(methods, m)
+		}
+		sort.Strings(methods)
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	h(w, r)
+}
+
+func (s *Server) listHabits(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.store.GetAll())
+}
+
+func (s *Server) listAchievements(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.store.GlobalAchievements())
+}
+
+func (s *Server) getHabit(w http.ResponseWriter, name string) {
+	h, ok := s.store.Get(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("habit '%s' is not tracked", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, h)
+}
+
+func (s *Server) deleteHabit(w http.ResponseWriter, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.store.Lock(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer s.store.Unlock()
+	if !s.store.Delete(name) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("habit '%s' is not tracked", name))
+		return
+	}
+	if err := s.store.Save(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// logHabit records the habit activity. The request body can
+// hold the time of the activity, so clients can replay logs
+// made while the server was not reachable.
+//
+// Bodies must be JSON, so browsers ask before sending them
+// from other origins.
+func (s *Server) logHabit(w http.ResponseWriter, r *http.Request, name string) {
+	req := logRequest{Date: Now()}
+	if r.ContentLength != 0 {
+		if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType, errors.New("request body must be application/json"))
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.store.Lock(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer s.store.Unlock()
+	msg, err := s.store.LogOn(name, req.Date)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.store.Save(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h, _ := s.store.Get(name)
+	writeJSON(w, http.StatusOK, logResponse{Message: msg, Habit: h})
+}
+
+func (s *Server) getStats(w http.ResponseWriter, name string) {
+	h, ok := s.store.Get(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("habit '%s' is not tracked", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, h.Stats())
+}
+
+func (s *Server) listStats(w http.ResponseWriter, r *http.Request) {
+	habits := s.store.GetAll()
+	stats := make([]Stats, 0, len(habits))
+	for _, h := range habits {
+		stats = append(stats, h.Stats())
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (s *Server) check(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, messageResponse{Message: Check(s.store)})
+}
+
+func (s *Server) metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	WriteMetrics(w, s.store.GetAll())
+}
+
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(openAPI)
+}
+
+func serveDashboard(w http.ResponseWriter, r *http.Request) {
+	web, err := fs.Sub(dashboard, "web")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	r = r.Clone(r.Context())
+	r.URL.Path = strings.TrimPrefix(r.URL.Path, "/assets")
+	http.FileServer(http.FS(web)).ServeHTTP(w, r)
+}
+
+type logRequest struct {
+	Date time.Time `json:"date"`
+}
+
+type logResponse struct {
+	Message string `json:"message"`
+	Habit   Habit  `json:"habit"`
+}
+
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// pathSegments returns unescaped segments of the URL path,
+// so habit names can contain escaped slashes and spaces.
+func pathSegments(u *url.URL) ([]string, error) {
+	var parts []string
+	for _, p := range strings.Split(strings.Trim(u.EscapedPath(), "/"), "/") {
+		if p == "" {
+			continue
+		}
+		seg, err := url.PathUnescape(p)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, seg)
+	}
+	return parts, nil
+}
+
+func runServe(s *FileStore, args []string, wr, ew io.Writer) int {
+	fset := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fset.SetOutput(ew)
+	addr := fset.String("addr", "127.0.0.1:8080", "address to listen on")
+	args, err := parseArgs(fset, args)
+	if err != nil {
+		return 1
+	}
+	if len(args) != 0 {
+		fmt.Fprint(ew, errors.New("usage: habit serve [--addr 127.0.0.1:8080]"))
+		return 1
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           NewServer(s),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(wr, "Serving habits on http://%s\n", *addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	return 0
+}
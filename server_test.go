@@ -0,0 +1,348 @@
+package habit_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/habit"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, *habit.FileStore) {
+	t.Helper()
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(habit.NewServer(store))
+	t.Cleanup(ts.Close)
+	return ts, store
+}
+
+func decode(t *testing.T, resp *http.Response, v any) {
+	t.Helper()
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServer_LogsHabitAndListsHabits(t *testing.T) {
+	setNow(t, time.Date(2022, 10, 4, 8, 0, 0, 0, time.UTC))
+	ts, _ := newTestServer(t)
+
+	resp, err := http.Post(ts.URL+"/habits/play%20piano/log", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("want status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var logged struct {
+		Message string      `json:"message"`
+		Habit   habit.Habit `json:"habit"`
+	}
+	decode(t, resp, &logged)
+	wantMsg := "Good luck with your new habit 'play piano'. Don't forget to do it tomorrow.\n"
+	if wantMsg != logged.Message {
+		t.Error(cmp.Diff(wantMsg, logged.Message))
+	}
+
+	resp, err = http.Get(ts.URL + "/habits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []habit.Habit
+	decode(t, resp, &got)
+	want := []habit.Habit{
+		{Name: "play piano", Date: day(4).Date, Streak: 1, History: []habit.Completion{loggedAt(day(4), time.Date(2022, 10, 4, 8, 0, 0, 0, time.UTC))}},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestServer_GetsHabitAndItsStats(t *testing.T) {
+	setNow(t, time.Date(2022, 10, 4, 8, 0, 0, 0, time.UTC))
+	ts, store := newTestServer(t)
+	store.Add(habit.Habit{Name: "jog", Date: day(4).Date, Streak: 2, History: []habit.Completion{day(3), day(4)}})
+
+	resp, err := http.Get(ts.URL + "/habits/jog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var h habit.Habit
+	decode(t, resp, &h)
+	if h.Streak != 2 {
+		t.Errorf("want streak 2, got %d", h.Streak)
+	}
+
+	resp, err = http.Get(ts.URL + "/habits/jog/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats habit.Stats
+	decode(t, resp, &stats)
+	if stats.TotalCompletions != 2 {
+		t.Errorf("want 2 completions, got %d", stats.TotalCompletions)
+	}
+}
+
+func TestServer_ListsGlobalAchievements(t *testing.T) {
+	setNow(t, day(7).Date)
+	ts, store := newTestServer(t)
+	store.Add(habit.Habit{Name: "jog", Date: day(6).Date, Streak: 6, History: days(1, 6)})
+	if _, err := store.LogOn("jog", day(7).Date); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []habit.Achievement{{ID: "perfect-week", Date: day(7).Date}}
+	if got := newTestHTTPStore(t, ts.URL).GlobalAchievements(); !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestServer_DeletesHabit(t *testing.T) {
+	ts, store := newTestServer(t)
+	store.Add(habit.Habit{Name: "jog", Date: day(4).Date, Streak: 1})
+
+	req, err := http.NewRequest(http.MethodDelete, ts.URL+"/habits/jog", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("want status %d, got %d", http.StatusNoContent, resp.StatusCode)
+	}
+	if _, ok := store.Get("jog"); ok {
+		t.Error("want habit deleted")
+	}
+
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("want status %d, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestServer_RespondsNotFoundOnNotTrackedHabit(t *testing.T) {
+	ts, _ := newTestServer(t)
+
+	resp, err := http.Get(ts.URL + "/habits/walk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("want status %d, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+	var got struct {
+		Error string `json:"error"`
+	}
+	decode(t, resp, &got)
+	want := "habit 'walk' is not tracked"
+	if want != got.Error {
+		t.Error(cmp.Diff(want, got.Error))
+	}
+}
+
+func TestServer_RejectsNotAllowedMethod(t *testing.T) {
+	ts, _ := newTestServer(t)
+
+	resp, err := http.Get(ts.URL + "/habits/jog/log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("want status %d, got %d", http.StatusMethodNotAllowed, resp.StatusCode)
+	}
+	if got := resp.Header.Get("Allow"); got != http.MethodPost {
+		t.Errorf("want Allow %q, got %q", http.MethodPost, got)
+	}
+}
+
+func TestServer_LogsHabitsConcurrently(t *testing.T) {
+	setNow(t, time.Date(2022, 10, 4, 8, 0, 0, 0, time.UTC))
+	ts, store := newTestServer(t)
+
+	names := []string{"jog", "read", "write", "swim", "bike"}
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			resp, err := http.Post(ts.URL+"/habits/"+name+"/log", "", nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}(name)
+	}
+	wg.Wait()
+
+	reloaded, err := habit.NewFileStore(store.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(reloaded.GetAll()); got != len(names) {
+		t.Errorf("want %d saved habits, got %d", len(names), got)
+	}
+}
+
+func TestServer_ServesHabitsLoggedByOtherProcesses(t *testing.T) {
+	setNow(t, time.Date(2022, 10, 4, 8, 0, 0, 0, time.UTC))
+	ts, store := newTestServer(t)
+	resp, err := http.Post(ts.URL+"/habits/jog/log", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// The command line logs a habit while the server runs.
+	cli, err := habit.NewFileStore(store.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Log("read"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.Save(); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = http.Post(ts.URL+"/habits/swim/log", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	reloaded, err := habit.NewFileStore(store.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, h := range reloaded.GetAll() {
+		names = append(names, h.Name)
+	}
+	want := []string{"jog", "read", "swim"}
+	if !cmp.Equal(want, names) {
+		t.Error(cmp.Diff(want, names))
+	}
+}
+
+func TestServer_RejectsLogAfterToday(t *testing.T) {
+	setNow(t, time.Date(2022, 10, 4, 8, 0, 0, 0, time.UTC))
+	ts, store := newTestServer(t)
+
+	resp, err := http.Post(ts.URL+"/habits/jog/log", "application/json", strings.NewReader(`{"date":"2030-01-01T00:00:00Z"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("want status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+	if _, ok := store.Get("jog"); ok {
+		t.Error("want habit logged after today not tracked")
+	}
+}
+
+func TestServer_RejectsCrossOriginChanges(t *testing.T) {
+	setNow(t, time.Date(2022, 10, 4, 8, 0, 0, 0, time.UTC))
+	ts, store := newTestServer(t)
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/habits/jog/log", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Origin", "https://evil.example.com")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("want status %d, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+	resp, err = http.Post(ts.URL+"/habits/jog/log", "text/plain", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("want status %d, got %d", http.StatusUnsupportedMediaType, resp.StatusCode)
+	}
+	if _, ok := store.Get("jog"); ok {
+		t.Error("want habit not logged by rejected requests")
+	}
+
+	req.Header.Set("Origin", ts.URL)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("want status %d of same-origin request, got %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestServer_ServesOpenAPIDescription(t *testing.T) {
+	ts, _ := newTestServer(t)
+
+	resp, err := http.Get(ts.URL + "/openapi.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "openapi: 3") {
+		t.Errorf("want OpenAPI description, got %q", data)
+	}
+}
+
+func TestServer_ServesDashboard(t *testing.T) {
+	ts, _ := newTestServer(t)
+
+	for path, want := range map[string]string{
+		"/":                 "<title>habit</title>",
+		"/assets/app.js":    "function renderHeatmap",
+		"/assets/style.css": ".heatmap",
+	} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: want status %d, got %d", path, http.StatusOK, resp.StatusCode)
+		}
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s: want body containing %q", path, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package habit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// SimilarHabitError is returned when a habit name is not
+// tracked but is similar to the name of a tracked habit,
+// which usually means a typo.
+type SimilarHabitError struct {
+	Name    string // Name holds the name which is not tracked.
+	Similar string // Similar holds the name of the similar tracked habit.
+}
+
+func (e *SimilarHabitError) Error() string {
+	return fmt.Sprintf("habit '%s' is not tracked. Did you mean '%s'? Run 'habit new %s' to start tracking a new habit.", e.Name, e.Similar, e.Name)
+}
+
+// SimilarHabit takes habits and a name and returns the habit with
+// the most similar name. Names are similar if they differ only
+// in case or are a few typos apart. It returns false if there is
+// no habit with a similar name.
+func SimilarHabit(hx []Habit, name string) (Habit, bool) {
+	name = strings.ToLower(name)
+	maxDist := 1
+	if utf8.RuneCountInString(name) > 5 {
+		maxDist = 2
+	}
+	var best Habit
+	bestDist := maxDist + 1
+	for _, h := range hx {
+		d := editDistance(strings.ToLower(h.Name), name)
+		if d < bestDist {
+			best, bestDist = h, d
+		}
+	}
+	return best, bestDist <= maxDist
+}
+
+// editDistance returns the optimal string alignment distance between
+// a and b: number of rune insertions, deletions, substitutions and
+// transpositions of adjacent runes needed to change a into b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// confirm prints the question and reports whether
+// the answer read from r is yes. Empty answer means yes.
+func confirm(r io.Reader, w io.Writer, question string) bool {
+	fmt.Fprint(w, question)
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes":
+		return true
+	}
+	return false
+}
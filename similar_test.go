@@ -0,0 +1,83 @@
+package habit_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/qba73/habit"
+)
+
+func TestSimilarHabit_FindsHabitWithSimilarName(t *testing.T) {
+	t.Parallel()
+
+	hx := []habit.Habit{{Name: "jog"}, {Name: "read"}, {Name: "play piano"}}
+	tt := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{name: "jgo", want: "jog", ok: true},
+		{name: "Jog", want: "jog", ok: true},
+		{name: "jogs", want: "jog", ok: true},
+		{name: "raed", want: "read", ok: true},
+		{name: "play pinao", want: "play piano", ok: true},
+		{name: "plya pinao", want: "play piano", ok: true},
+		{name: "swim", ok: false},
+		{name: "write", ok: false},
+	}
+	for _, tc := range tt {
+		got, ok := habit.SimilarHabit(hx, tc.name)
+		if tc.ok != ok {
+			t.Errorf("%s: want %t, got %t", tc.name, tc.ok, ok)
+			continue
+		}
+		if ok && tc.want != got.Name {
+			t.Errorf("%s: want %q, got %q", tc.name, tc.want, got.Name)
+		}
+	}
+}
+
+func TestRecord_ErrorsOnNameSimilarToTrackedHabit(t *testing.T) {
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := habit.Record(store, "jog"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = habit.Record(store, "jgo")
+	var similar *habit.SimilarHabitError
+	if !errors.As(err, &similar) {
+		t.Fatalf("want SimilarHabitError, got %v", err)
+	}
+	if similar.Similar != "jog" {
+		t.Errorf("want suggestion 'jog', got %q", similar.Similar)
+	}
+	if _, ok := store.Get("jgo"); ok {
+		t.Error("want habit 'jgo' not created")
+	}
+}
+
+func TestCreate_StartsTrackingHabitWithSimilarName(t *testing.T) {
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := habit.Record(store, "jog"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Good luck with your new habit 'Jog'. Don't forget to do it tomorrow.\n"
+	got, err := habit.Create(store, "Jog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	if _, err := habit.Create(store, "jog"); err == nil {
+		t.Error("want error on creating tracked habit, got nil")
+	}
+}
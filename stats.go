@@ -0,0 +1,199 @@
+package habit
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// statsWindows holds lengths, in days, of windows
+// used to calculate completion rates.
+var statsWindows = []int{7, 30, 90, 365}
+
+// Stats holds statistics of a tracked habit.
+type Stats struct {
+	Name                   string    `json:"name"`
+	FirstTracked           time.Time `json:"first_tracked"`
+	TotalCompletions       int       `json:"total_completions"`
+	CurrentStreak          int       `json:"current_streak"`
+	BestStreak             int       `json:"best_streak"`
+	AverageStreak          float64   `json:"average_streak"`
+	Rate7                  float64   `json:"rate_7d"`
+	Rate30                 float64   `json:"rate_30d"`
+	Rate90                 float64   `json:"rate_90d"`
+	Rate365                float64   `json:"rate_365d"`
+	MostConsistentWeekday  string    `json:"most_consistent_weekday"`
+	LeastConsistentWeekday string    `json:"least_consistent_weekday"`
+}
+
+// Stats returns statistics of the habit calculated
+// from days when the habit was done.
+//
+// Completion rates are fractions of days in the window
+// when the habit was done. Windows longer than the time
+// the habit is tracked are shortened to the tracked period.
+func (h *Habit) Stats() Stats {
+	s := Stats{Name: h.Name}
+	days := h.Days()
+	if len(days) == 0 {
+		return s
+	}
+	today := RoundDateToDay(Now())
+	s.FirstTracked = days[0]
+	s.TotalCompletions = len(days)
+
+	gap := h.Frequency.gap()
+	runs := streaks(days, gap)
+	sum := 0
+	for _, r := range runs {
+		sum += r
+		if r > s.BestStreak {
+			s.BestStreak = r
+		}
+	}
+	s.AverageStreak = float64(sum) / float64(len(runs))
+	if DayDiff(days[len(days)-1], today) <= gap {
+		s.CurrentStreak = runs[len(runs)-1]
+	}
+
+	tracked := DayDiff(s.FirstTracked, today) + 1
+	rates := make([]float64, len(statsWindows))
+	for i, window := range statsWindows {
+		if window > tracked {
+			window = tracked
+		}
+		from := today.AddDate(0, 0, -window+1)
+		done := 0
+		for _, d := range days {
+			if !d.Before(from) && !d.After(today) {
+				done++
+			}
+		}
+		rates[i] = float64(done) / float64(window)
+	}
+	s.Rate7, s.Rate30, s.Rate90, s.Rate365 = rates[0], rates[1], rates[2], rates[3]
+
+	s.MostConsistentWeekday, s.LeastConsistentWeekday = weekdayConsistency(days, today)
+	return s
+}
+
+// streaks takes sorted days and returns lengths of runs of days,
+// oldest first. Days in a run are at most gap days apart.
+func streaks(days []time.Time, gap int) []int {
+	var runs []int
+	for i, d := range days {
+		if i > 0 && DayDiff(days[i-1], d) <= gap {
+			runs[len(runs)-1]++
+			continue
+		}
+		runs = append(runs, 1)
+	}
+	return runs
+}
+
+// weekdayConsistency returns weekdays with the highest and the lowest
+// completion rate between the first day and today. It returns empty
+// strings if the habit wasn't done on any day up to today.
+func weekdayConsistency(days []time.Time, today time.Time) (string, string) {
+	var done, total [7]int
+	for d := days[0]; !d.After(today); d = d.AddDate(0, 0, 1) {
+		total[d.Weekday()]++
+	}
+	for _, d := range days {
+		if !d.After(today) {
+			done[d.Weekday()]++
+		}
+	}
+	most, least := -1, -1
+	var mostRate, leastRate float64
+	for wd := 0; wd < 7; wd++ {
+		if total[wd] == 0 {
+			continue
+		}
+		rate := float64(done[wd]) / float64(total[wd])
+		if most == -1 || rate > mostRate {
+			most, mostRate = wd, rate
+		}
+		if least == -1 || rate < leastRate {
+			least, leastRate = wd, rate
+		}
+	}
+	if most == -1 {
+		return "", ""
+	}
+	return time.Weekday(most).String(), time.Weekday(least).String()
+}
+
+// String returns human readable statistics.
+func (s Stats) String() string {
+	if s.TotalCompletions == 0 {
+		return fmt.Sprintf("%s\n  No completions yet.\n", s.Name)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\n", s.Name)
+	fmt.Fprintf(&sb, "  First tracked:          %s\n", s.FirstTracked.Format("2006-01-02"))
+	fmt.Fprintf(&sb, "  Total completions:      %d\n", s.TotalCompletions)
+	fmt.Fprintf(&sb, "  Current streak:         %d\n", s.CurrentStreak)
+	fmt.Fprintf(&sb, "  Best streak:            %d\n", s.BestStreak)
+	fmt.Fprintf(&sb, "  Average streak:         %.1f\n", s.AverageStreak)
+	fmt.Fprintf(&sb, "  Last 7 days:            %.0f%%\n", s.Rate7*100)
+	fmt.Fprintf(&sb, "  Last 30 days:           %.0f%%\n", s.Rate30*100)
+	fmt.Fprintf(&sb, "  Last 90 days:           %.0f%%\n", s.Rate90*100)
+	fmt.Fprintf(&sb, "  Last 365 days:          %.0f%%\n", s.Rate365*100)
+	if s.MostConsistentWeekday != "" {
+		fmt.Fprintf(&sb, "  Most consistent day:    %s\n", s.MostConsistentWeekday)
+		fmt.Fprintf(&sb, "  Least consistent day:   %s\n", s.LeastConsistentWeekday)
+	}
+	return sb.String()
+}
+
+func runStats(s Store, args []string, wr, ew io.Writer) int {
+	fset := flag.NewFlagSet("stats", flag.ContinueOnError)
+	fset.SetOutput(ew)
+	asJSON := fset.Bool("json", false, "print statistics in JSON format")
+	args, err := parseArgs(fset, args)
+	if err != nil {
+		return 1
+	}
+	if len(args) > 1 {
+		fmt.Fprint(ew, errors.New("usage: habit stats [name] [--json]"))
+		return 1
+	}
+	var name string
+	if len(args) == 1 {
+		name = args[0]
+	}
+	habits, err := selectHabits(s, name)
+	if err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	stats := make([]Stats, 0, len(habits))
+	for _, h := range habits {
+		stats = append(stats, h.Stats())
+	}
+	if *asJSON {
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			fmt.Fprint(ew, err)
+			return 1
+		}
+		fmt.Fprintln(wr, string(data))
+		return 0
+	}
+	if len(stats) == 0 {
+		fmt.Fprint(wr, message(msgNotTracking, 0, MessageData{}))
+		return 0
+	}
+	for i, st := range stats {
+		if i > 0 {
+			fmt.Fprintln(wr)
+		}
+		fmt.Fprint(wr, st)
+	}
+	return 0
+}
@@ -0,0 +1,105 @@
+package habit_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/qba73/habit"
+)
+
+func TestStats_CalculatesStatisticsFromHabitHistory(t *testing.T) {
+	setNow(t, time.Date(2022, 10, 12, 8, 0, 0, 0, time.UTC))
+
+	h := habit.Habit{
+		Name:    "jog",
+		Date:    day(12).Date,
+		Streak:  3,
+		History: []habit.Completion{day(3), day(4), day(5), day(8), day(10), day(11), day(12)},
+	}
+
+	got := h.Stats()
+	want := habit.Stats{
+		Name:                   "jog",
+		FirstTracked:           day(3).Date,
+		TotalCompletions:       7,
+		CurrentStreak:          3,
+		BestStreak:             3,
+		AverageStreak:          7.0 / 3,
+		Rate7:                  4.0 / 7,
+		Rate30:                 0.7,
+		Rate90:                 0.7,
+		Rate365:                0.7,
+		MostConsistentWeekday:  "Monday",
+		LeastConsistentWeekday: "Sunday",
+	}
+	if !cmp.Equal(want, got, cmpopts.EquateApprox(0, 1e-9)) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestStats_ReportsNoCurrentStreakOnBrokenStreak(t *testing.T) {
+	setNow(t, time.Date(2022, 10, 20, 8, 0, 0, 0, time.UTC))
+
+	h := habit.Habit{
+		Name:    "jog",
+		Date:    day(12).Date,
+		Streak:  3,
+		History: []habit.Completion{day(10), day(11), day(12)},
+	}
+
+	got := h.Stats()
+	if got.CurrentStreak != 0 {
+		t.Errorf("want current streak 0, got %d", got.CurrentStreak)
+	}
+	if got.BestStreak != 3 {
+		t.Errorf("want best streak 3, got %d", got.BestStreak)
+	}
+}
+
+func TestStats_SkipsWeekdaysWithoutPastDays(t *testing.T) {
+	setNow(t, day(10).Date)
+
+	h := habit.Habit{Name: "jog", Date: day(12).Date, Streak: 1, History: []habit.Completion{day(12)}}
+	got := h.Stats()
+	if got.MostConsistentWeekday != "" || got.LeastConsistentWeekday != "" {
+		t.Errorf("want no weekdays without days done until today, got %q and %q", got.MostConsistentWeekday, got.LeastConsistentWeekday)
+	}
+	if s := got.String(); strings.Contains(s, "consistent day") {
+		t.Errorf("want no consistent day lines, got:\n%s", s)
+	}
+}
+
+func TestStats_AllowsRestDaysOfHabitWithFrequency(t *testing.T) {
+	setNow(t, day(14).Date)
+
+	h := habit.Habit{
+		Name:      "gym",
+		Date:      day(12).Date,
+		Streak:    5,
+		History:   []habit.Completion{day(1), day(3), day(5), day(8), day(10), day(12)},
+		Frequency: &habit.Frequency{Times: 3, Days: 7},
+	}
+	got := h.Stats()
+	if got.CurrentStreak != 6 || got.BestStreak != 6 {
+		t.Errorf("want current and best streak 6, got %d and %d", got.CurrentStreak, got.BestStreak)
+	}
+}
+
+func TestStats_BreaksStreakOfHabitWithFrequencyAfterLongRest(t *testing.T) {
+	setNow(t, day(17).Date)
+
+	h := habit.Habit{
+		Name:      "gym",
+		Date:      day(11).Date,
+		Streak:    1,
+		History:   []habit.Completion{day(1), day(3), day(5), day(11)},
+		Frequency: &habit.Frequency{Times: 3, Days: 7},
+	}
+	got := h.Stats()
+	if got.CurrentStreak != 0 || got.BestStreak != 3 {
+		t.Errorf("want current streak 0 and best streak 3, got %d and %d", got.CurrentStreak, got.BestStreak)
+	}
+}
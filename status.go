@@ -0,0 +1,178 @@
+package habit
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Status summarises habits for status lines and shell prompts.
+type Status struct {
+	Done     int      // Done holds number of habits done today.
+	Due      int      // Due holds number of habits due today.
+	Streak   int      // Streak holds the longest current streak.
+	DueNames []string // DueNames holds names of habits due today.
+}
+
+// NewStatus takes habits and returns their status for today.
+func NewStatus(hx []Habit) Status {
+	var s Status
+	today := RoundDateToDay(Now())
+	for _, h := range hx {
+		if h.DoneOn(today) {
+			s.Done++
+		}
+		if h.Due() {
+			s.Due++
+			s.DueNames = append(s.DueNames, h.Name)
+		}
+		if streak := h.Stats().CurrentStreak; streak > s.Streak {
+			s.Streak = streak
+		}
+	}
+	return s
+}
+
+// Format returns the status in the given format:
+// plain, tmux, waybar or i3blocks.
+func (s Status) Format(format string) (string, error) {
+	text := fmt.Sprintf("✔%d ✗%d 🔥%d", s.Done, s.Due, s.Streak)
+	switch format {
+	case "plain":
+		return text + "\n", nil
+	case "tmux":
+		return fmt.Sprintf("#[fg=green]✔%d#[default] #[fg=red]✗%d#[default] 🔥%d\n", s.Done, s.Due, s.Streak), nil
+	case "i3blocks":
+		// i3blocks reads full text, short text and colour lines.
+		color := "#00FF00"
+		if s.Due > 0 {
+			color = "#FF0000"
+		}
+		return fmt.Sprintf("%s\n✗%d\n%s\n", text, s.Due, color), nil
+	case "waybar":
+		class := "done"
+		if s.Due > 0 {
+			class = "due"
+		}
+		tooltip := "All habits done for today."
+		if s.Due > 0 {
+			tooltip = "Due: " + strings.Join(s.DueNames, ", ")
+		}
+		percentage := 100
+		if total := s.Done + s.Due; total > 0 {
+			percentage = s.Done * 100 / total
+		}
+		data, err := json.Marshal(struct {
+			Text       string `json:"text"`
+			Tooltip    string `json:"tooltip"`
+			Class      string `json:"class"`
+			Percentage int    `json:"percentage"`
+		}{text, tooltip, class, percentage})
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	}
+	return "", fmt.Errorf("unsupported status format: %s", format)
+}
+
+// statusCache holds formatted status valid as long as
+// the store file and the day don't change.
+type statusCache struct {
+	StorePath    string    `json:"store_path"`
+	StoreModTime time.Time `json:"store_mod_time"`
+	StoreSize    int64     `json:"store_size"`
+	Day          time.Time `json:"day"`
+	Format       string    `json:"format"`
+	Output       string    `json:"output"`
+}
+
+// cachedStatus returns the status stored in the cache file if it
+// was formatted today for the current content of the store file.
+func cachedStatus(cachePath string, key statusCache) (string, bool) {
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return "", false
+	}
+	var c statusCache
+	if err := json.Unmarshal(data, &c); err != nil {
+		return "", false
+	}
+	if c.StorePath != key.StorePath || !c.StoreModTime.Equal(key.StoreModTime) || c.StoreSize != key.StoreSize || !c.Day.Equal(key.Day) || c.Format != key.Format {
+		return "", false
+	}
+	return c.Output, true
+}
+
+// encryptedStore reports whether the store file at path is encrypted.
+func encryptedStore(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, len(encryptedMagic))
+	n, _ := io.ReadFull(f, head)
+	return IsEncrypted(head[:n])
+}
+
+// runStatus prints status of habits. If a cache file is given and
+// the store file at storePath didn't change, the store is not opened.
+//
+// Status of encrypted stores is never cached, as it can hold
+// names of habits.
+func runStatus(open func() (Store, error), storePath string, args []string, wr, ew io.Writer) int {
+	fset := flag.NewFlagSet("status", flag.ContinueOnError)
+	fset.SetOutput(ew)
+	format := fset.String("format", "plain", "output format: plain, tmux, waybar or i3blocks")
+	cachePath := fset.String("cache", "", "path to the cache file used to skip reading the store when it didn't change")
+	args, err := parseArgs(fset, args)
+	if err != nil {
+		return 1
+	}
+	if len(args) != 0 {
+		fmt.Fprint(ew, errors.New("usage: habit status [--format plain|tmux|waybar|i3blocks] [--cache file]"))
+		return 1
+	}
+
+	var key statusCache
+	useCache := *cachePath != "" && storePath != "" && !encryptedStore(storePath)
+	if useCache {
+		key = statusCache{StorePath: storePath, Day: RoundDateToDay(Now()), Format: *format}
+		if abs, err := filepath.Abs(storePath); err == nil {
+			key.StorePath = abs
+		}
+		if fi, err := os.Stat(storePath); err == nil {
+			key.StoreModTime, key.StoreSize = fi.ModTime(), fi.Size()
+		}
+		if out, ok := cachedStatus(*cachePath, key); ok {
+			fmt.Fprint(wr, out)
+			return 0
+		}
+	}
+
+	s, err := open()
+	if err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	out, err := NewStatus(s.GetAll()).Format(*format)
+	if err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	fmt.Fprint(wr, out)
+	if useCache {
+		key.Output = out
+		if data, err := json.Marshal(key); err == nil {
+			os.WriteFile(*cachePath, data, 0o600)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,55 @@
+package habit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/habit"
+)
+
+func TestNewStatus_SummarisesHabitsForToday(t *testing.T) {
+	setNow(t, time.Date(2022, 10, 12, 8, 0, 0, 0, time.UTC))
+
+	hx := []habit.Habit{
+		{Name: "jog", Date: day(12).Date, Streak: 3, History: []habit.Completion{day(10), day(11), day(12)}},
+		{Name: "read", Date: day(11).Date, Streak: 1, History: []habit.Completion{day(11)}},
+		{Name: "swim", Date: day(1).Date, Streak: 1, History: []habit.Completion{day(1)}},
+	}
+
+	got := habit.NewStatus(hx)
+	want := habit.Status{Done: 1, Due: 2, Streak: 3, DueNames: []string{"read", "swim"}}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestStatusFormat_FormatsStatusForStatusLines(t *testing.T) {
+	t.Parallel()
+
+	s := habit.Status{Done: 3, Due: 2, Streak: 41, DueNames: []string{"read", "swim"}}
+	tt := map[string]string{
+		"plain":    "✔3 ✗2 🔥41\n",
+		"tmux":     "#[fg=green]✔3#[default] #[fg=red]✗2#[default] 🔥41\n",
+		"i3blocks": "✔3 ✗2 🔥41\n✗2\n#FF0000\n",
+		"waybar":   `{"text":"✔3 ✗2 🔥41","tooltip":"Due: read, swim","class":"due","percentage":60}` + "\n",
+	}
+	for format, want := range tt {
+		got, err := s.Format(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want != got {
+			t.Errorf("%s: %s", format, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestStatusFormat_ErrorsOnUnsupportedFormat(t *testing.T) {
+	t.Parallel()
+
+	_, err := habit.Status{}.Format("polybar")
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+}
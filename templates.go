@@ -0,0 +1,171 @@
+package habit
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// templateFiles maps names of template files
+// to keys of messages they override.
+var templateFiles = map[string]string{
+	"start.tmpl":        msgStart,
+	"streak.tmpl":       msgStreak,
+	"broken.tmpl":       msgBroken,
+	"recorded-on.tmpl":  msgRecordedOn,
+	"new-streak.tmpl":   msgNewStreak,
+	"continued.tmpl":    msgContinued,
+	"milestone.tmpl":    msgMilestone,
+	"not-tracking.tmpl": msgNotTracking,
+	"achievement.tmpl":  msgAchievement,
+	"all-done.tmpl":     msgAllDone,
+
+	"challenge-day.tmpl":       msgChallengeDay,
+	"challenge-completed.tmpl": msgChallengeCompleted,
+	"challenge-failed.tmpl":    msgChallengeFailed,
+}
+
+// MessageData holds fields available in message templates.
+type MessageData struct {
+	Name       string
+	Streak     int
+	BestStreak int
+	DaysSince  int    // DaysSince holds number of days since the habit was last done, before recording it.
+	Date       string // Date holds the day recorded, set only for habits recorded on past days.
+
+	Achievement string // Achievement holds the name of the achievement unlocked, set only for achievement messages.
+
+	// Challenge fields are set only for challenge messages.
+	ChallengeDay  int // ChallengeDay holds the day of the challenge going on.
+	ChallengeDays int // ChallengeDays holds the number of days of the challenge.
+	ChallengeDone int // ChallengeDone holds the number of days done of the ended challenge.
+}
+
+// templates holds message templates in use, by message key.
+var templates map[string]*template.Template
+
+// LoadTemplates takes a directory and loads message templates
+// from files in it, named after messages they override:
+// start.tmpl, streak.tmpl, broken.tmpl, recorded-on.tmpl,
+// new-streak.tmpl, continued.tmpl, milestone.tmpl, not-tracking.tmpl,
+// achievement.tmpl, all-done.tmpl, challenge-day.tmpl,
+// challenge-completed.tmpl and challenge-failed.tmpl.
+// Messages without a template are translated as usual.
+//
+// Templates are parsed with text/template and executed with
+// MessageData. It returns an error reporting every file that
+// is unknown, fails to parse or refers to unknown fields,
+// and then keeps templates in use unchanged. A missing
+// directory is not an error.
+func LoadTemplates(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		templates = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	loaded := make(map[string]*template.Template)
+	var errs []error
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		key, ok := templateFiles[e.Name()]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown message template, want one of: %s", path, strings.Join(templateNames(), ", ")))
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tmpl, err := parseTemplate(e.Name(), string(data))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		loaded[key] = tmpl
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("loading message templates: %w", errors.Join(errs...))
+	}
+	templates = loaded
+	return nil
+}
+
+// parseTemplate parses the template and validates it by
+// executing it with sample data, so templates referring
+// to unknown fields are reported before they are used.
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	sample := MessageData{Name: "habit", Streak: 2, BestStreak: 3, DaysSince: 1, Date: "2006-01-02", Achievement: "Perfect week", ChallengeDay: 12, ChallengeDays: 30, ChallengeDone: 25}
+	if err := tmpl.Execute(&strings.Builder{}, sample); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+func templateNames() []string {
+	names := make([]string, 0, len(templateFiles))
+	for name := range templateFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// message returns the message with the given key rendered with
+// the user's template, if there is one, or translated to the
+// language in use. Translations are formatted with args,
+// in the plural form matching n.
+//
+// Rendered messages end with a single newline,
+// unless the template renders only whitespace.
+func message(key string, n int, data MessageData, args ...any) string {
+	tmpl, ok := templates[key]
+	if !ok {
+		return translate(key, n, args...)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return translate(key, n, args...)
+	}
+	msg := strings.TrimRight(sb.String(), "\n")
+	if strings.TrimSpace(msg) == "" {
+		return ""
+	}
+	return msg + "\n"
+}
+
+// messageData returns data of the habit for message templates.
+func (h *Habit) messageData(daysSince int) MessageData {
+	data := MessageData{Name: h.Name, Streak: h.Streak, DaysSince: daysSince}
+	for _, s := range streaks(h.Days(), h.Frequency.gap()) {
+		data.BestStreak = max(data.BestStreak, s)
+	}
+	return data
+}
+
+// templateDir returns path to the directory with message templates.
+//
+// It's the habit/templates directory in the user's config
+// directory, $XDG_CONFIG_HOME or $HOME/.config on Unix.
+func templateDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "habit", "templates")
+}
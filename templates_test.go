@@ -0,0 +1,102 @@
+package habit_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qba73/habit"
+)
+
+// loadTemplates writes the template files to a temporary
+// directory and loads message templates from it.
+func loadTemplates(t *testing.T, files map[string]string) error {
+	t.Helper()
+	dir := t.TempDir()
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { habit.LoadTemplates(t.TempDir()) })
+	return habit.LoadTemplates(dir)
+}
+
+func TestLoadTemplates_OverridesMessages(t *testing.T) {
+	err := loadTemplates(t, map[string]string{
+		"continued.tmpl":    "{{.Name}}: {{.Streak}} (best {{.BestStreak}})\n",
+		"broken.tmpl":       "{{.Name}}: {{.DaysSince}} days since",
+		"not-tracking.tmpl": "\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNow(t, day(12).Date)
+
+	h := habit.Habit{Name: "jog", History: []habit.Completion{day(1), day(2), day(3), day(4), day(8)}}
+	h.Date, h.Streak = day(8).Date, 1
+	_, got := h.Check()
+	if want := "jog: 4 days since\n"; want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	_, got = h.RecordOn(day(9).Date)
+	if want := "jog: 2 (best 4)\n"; want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	_, got = h.RecordOn(day(11).Date)
+	if want := "You last did the habit 'jog' 2 days ago, so you're starting a new streak today. Good luck!\n"; want != got {
+		t.Errorf("want translated message for habit without template, got %q", got)
+	}
+
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := habit.Check(store); got != "" {
+		t.Errorf("want empty message, got %q", got)
+	}
+}
+
+func TestLoadTemplates_PassesBestStreakOfHabitWithFrequency(t *testing.T) {
+	err := loadTemplates(t, map[string]string{"continued.tmpl": "{{.Name}}: {{.Streak}} (best {{.BestStreak}})\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNow(t, day(8).Date)
+
+	h := habit.Habit{Name: "gym", Date: day(5).Date, Streak: 3, History: []habit.Completion{day(1), day(3), day(5)}, Frequency: &habit.Frequency{Times: 3, Days: 7}}
+	_, got := h.RecordOn(day(8).Date)
+	if want := "gym: 4 (best 4)\n"; want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestLoadTemplates_ErrorsOnInvalidTemplates(t *testing.T) {
+	err := loadTemplates(t, map[string]string{
+		"streak.tmpl":  "{{.Name",
+		"start.tmpl":   "{{.Nmae}}",
+		"started.tmpl": "{{.Name}}",
+		"broken.tmpl":  "{{.Name}}",
+	})
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+	for _, want := range []string{"streak.tmpl", "start.tmpl", "Nmae", "started.tmpl: unknown message template"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("want error mentioning %q, got %v", want, err)
+		}
+	}
+	h := habit.Habit{Name: "jog"}
+	if got := h.Start(); !strings.HasPrefix(got, "Good luck") {
+		t.Errorf("want templates not loaded on error, got %q", got)
+	}
+}
+
+func TestLoadTemplates_IgnoresMissingDirectory(t *testing.T) {
+	if err := habit.LoadTemplates(filepath.Join(t.TempDir(), "templates")); err != nil {
+		t.Error(err)
+	}
+}
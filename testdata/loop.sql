@@ -0,0 +1,45 @@
+-- Generates loop.db, a Loop Habit Tracker backup read by
+-- TestImportLoopDB_*. Regenerate it with go generate.
+--
+-- Jog's description spills to overflow pages, Gym is done
+-- 3 times a week, Read is measured in pages and Stretch has
+-- repetitions on many pages of the table b-tree.
+PRAGMA page_size = 4096;
+
+CREATE TABLE android_metadata (locale TEXT);
+CREATE TABLE Habits ( id integer primary key autoincrement, archived integer, color integer, description text, freq_den integer, freq_num integer, highlight integer, name text, position integer, reminder_hour integer, reminder_min integer, reminder_days integer not null default 127, type integer not null default 0, target_type integer not null default 0, target_value real not null default 0, unit text not null default "", question text, uuid text);
+CREATE TABLE Repetitions ( id integer primary key autoincrement, habit integer not null references habits(id), timestamp integer not null, value integer not null);
+CREATE UNIQUE INDEX idx_repetitions_habit_timestamp on Repetitions( habit, timestamp);
+
+INSERT INTO Habits (id, archived, color, description, freq_den, freq_num, highlight, name, position, type, target_type, target_value, unit, question, uuid)
+WITH RECURSIVE d(n, s) AS (
+	SELECT 1, 'Run every day before work. '
+	UNION ALL SELECT n + 1, s || 'Run every day before work. ' FROM d WHERE n < 300
+)
+SELECT 1, 0, 8, s, 1, 1, 0, 'Jog', 0, 0, 0, 0.0, '', 'Did you jog today?', 'a' FROM d WHERE n = 300;
+INSERT INTO Habits (id, archived, color, description, freq_den, freq_num, highlight, name, position, type, target_type, target_value, unit, question, uuid) VALUES
+	(2, 0, 8, '', 7, 3, 0, 'Gym', 1, 0, 0, 0.0, '', NULL, 'b'),
+	(3, 0, 8, '', 1, 1, 0, 'Read', 2, 1, 0, 10.0, 'pages', 'How many pages?', 'c'),
+	(4, 1, 8, '', 1, 1, 0, 'Stretch', 3, 0, 0, 0.0, '', NULL, 'd');
+
+-- Timestamps are milliseconds since the epoch, at midnight UTC.
+-- Values of checkmarks: 2 is checked by the user, 0 is not done
+-- and 3 is skipped; numerical habits store the amount times 1000.
+INSERT INTO Repetitions (id, habit, timestamp, value) VALUES
+	(1, 1, 1664668800000, 2),
+	(2, 1, 1664755200000, 2),
+	(3, 1, 1664841600000, 2),
+	(4, 1, 1664928000000, 2),
+	(5, 1, 1665014400000, 0),
+	(6, 2, 1664755200000, 2),
+	(7, 2, 1664928000000, 2),
+	(8, 2, 1665100800000, 2),
+	(9, 2, 1665360000000, 2),
+	(10, 2, 1665187200000, 3),
+	(11, 3, 1664841600000, 12500),
+	(12, 3, 1664928000000, 0);
+
+-- Stretch is done every day of 1500 days from 2018-01-01.
+INSERT INTO Repetitions (id, habit, timestamp, value)
+WITH RECURSIVE r(n) AS (SELECT 0 UNION ALL SELECT n + 1 FROM r WHERE n < 1499)
+SELECT 13 + n, 4, 1514764800000 + n * 86400000, 2 FROM r;
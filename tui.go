@@ -0,0 +1,417 @@
+package habit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// tuiKey represents a key pressed in the terminal UI.
+type tuiKey int
+
+const (
+	keyRune tuiKey = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyEnter
+	keyBackspace
+	keyEscape
+	keyInterrupt
+)
+
+// keyPress holds a key and, for keyRune, the character typed.
+type keyPress struct {
+	key tuiKey
+	r   rune
+}
+
+// parseKeys splits input read from a terminal in raw mode into keys.
+// Arrow keys are recognised in both the normal and the application
+// cursor mode. Other escape sequences, including keys pressed with
+// Alt, are skipped whole, so their bytes aren't taken for keys.
+// A lone escape is reported as keyEscape.
+func parseKeys(b []byte) []keyPress {
+	arrows := map[byte]tuiKey{'A': keyUp, 'B': keyDown, 'C': keyRight, 'D': keyLeft}
+	var keys []keyPress
+	for len(b) > 0 {
+		switch {
+		case b[0] == 0x1b && len(b) >= 2 && b[1] == '[':
+			// CSI sequences end with a byte in the range @ to ~,
+			// after parameter and intermediate bytes.
+			end := 2
+			for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+				end++
+			}
+			if end < len(b) {
+				if k, ok := arrows[b[end]]; ok {
+					keys = append(keys, keyPress{key: k})
+				}
+			}
+			b = b[min(end+1, len(b)):]
+			continue
+		case b[0] == 0x1b && len(b) >= 3 && b[1] == 'O':
+			if k, ok := arrows[b[2]]; ok {
+				keys = append(keys, keyPress{key: k})
+			}
+			b = b[3:]
+			continue
+		case b[0] == 0x1b && len(b) >= 2 && b[1] != 0x1b:
+			// Alt and the key, skipping the rest of a multibyte character.
+			_, size := utf8.DecodeRune(b[1:])
+			b = b[1+size:]
+			continue
+		case b[0] == 0x1b:
+			keys = append(keys, keyPress{key: keyEscape})
+		case b[0] == '\r' || b[0] == '\n':
+			keys = append(keys, keyPress{key: keyEnter})
+		case b[0] == 0x7f || b[0] == 0x08:
+			keys = append(keys, keyPress{key: keyBackspace})
+		case b[0] == 0x03 || b[0] == 0x04:
+			keys = append(keys, keyPress{key: keyInterrupt})
+		default:
+			r, size := utf8.DecodeRune(b)
+			if unicode.IsPrint(r) {
+				keys = append(keys, keyPress{key: keyRune, r: r})
+			}
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// tuiModel holds state of the terminal UI. It handles keys
+// and renders the screen, without touching the terminal.
+type tuiModel struct {
+	store   Store
+	habits  []Habit
+	cursor  int       // cursor holds the index of the selected habit.
+	day     time.Time // day holds the day selected in the calendar.
+	editing bool      // editing reports whether the note of the day is typed.
+	input   []rune
+	status  string
+	width   int
+	color   bool
+}
+
+func newTUIModel(s Store, width int, color bool) *tuiModel {
+	m := &tuiModel{store: s, day: RoundDateToDay(Now()), width: width, color: color}
+	m.reload()
+	return m
+}
+
+// reload reads habits from the store, keeping the selected one.
+func (m *tuiModel) reload() {
+	name := ""
+	if h, ok := m.selected(); ok {
+		name = h.Name
+	}
+	m.habits = m.store.GetAll()
+	m.cursor = min(m.cursor, max(len(m.habits)-1, 0))
+	for i, h := range m.habits {
+		if h.Name == name {
+			m.cursor = i
+		}
+	}
+}
+
+func (m *tuiModel) selected() (Habit, bool) {
+	if m.cursor >= len(m.habits) {
+		return Habit{}, false
+	}
+	return m.habits[m.cursor], true
+}
+
+// handle takes a key and updates the model.
+// It returns false if the UI should quit.
+func (m *tuiModel) handle(k keyPress) bool {
+	if m.editing {
+		m.handleNote(k)
+		return true
+	}
+	today := RoundDateToDay(Now())
+	switch {
+	case k.key == keyInterrupt || k.key == keyRune && k.r == 'q':
+		return false
+	case k.key == keyUp || k.key == keyRune && k.r == 'k':
+		m.cursor = max(m.cursor-1, 0)
+	case k.key == keyDown || k.key == keyRune && k.r == 'j':
+		m.cursor = min(m.cursor+1, max(len(m.habits)-1, 0))
+	case k.key == keyLeft || k.key == keyRune && k.r == 'h':
+		m.day = m.day.AddDate(0, 0, -1)
+	case k.key == keyRight || k.key == keyRune && k.r == 'l':
+		if m.day.Before(today) {
+			m.day = m.day.AddDate(0, 0, 1)
+		}
+	case k.key == keyRune && k.r == 't':
+		m.day = today
+	case k.key == keyRune && k.r == ' ':
+		m.locked(m.toggleToday)
+	case k.key == keyRune && k.r == 'b':
+		m.locked(m.backfill)
+	case k.key == keyRune && k.r == 'n':
+		h, ok := m.selected()
+		if !ok {
+			return true
+		}
+		if !h.DoneOn(m.day) {
+			m.status = fmt.Sprintf("Habit '%s' was not done on %s, record it before adding a note.", h.Name, m.day.Format(dateLayout))
+			return true
+		}
+		m.editing, m.input = true, []rune(completionOn(h, m.day).Note)
+		m.status = ""
+	}
+	return true
+}
+
+func (m *tuiModel) handleNote(k keyPress) {
+	switch k.key {
+	case keyEscape, keyInterrupt:
+		m.editing = false
+	case keyBackspace:
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case keyRune:
+		m.input = append(m.input, k.r)
+	case keyEnter:
+		m.editing = false
+		fstore, ok := m.store.(*FileStore)
+		if !ok {
+			m.status = "Notes can be added only to a local file store."
+			return
+		}
+		m.locked(func() {
+			h, ok := m.selected()
+			if !ok {
+				return
+			}
+			if err := fstore.SetNote(h.Name, m.day, strings.TrimSpace(string(m.input))); err != nil {
+				m.status = err.Error()
+				return
+			}
+			m.save(fmt.Sprintf("Saved note of '%s' on %s.", h.Name, m.day.Format(dateLayout)))
+		})
+	}
+}
+
+// locked runs the change of habits holding the lock of a file
+// store, with habits reloaded, so changes made by other processes
+// since the UI started are neither lost nor overwritten.
+func (m *tuiModel) locked(change func()) {
+	fstore, ok := m.store.(*FileStore)
+	if !ok {
+		change()
+		return
+	}
+	if err := fstore.Lock(); err != nil {
+		m.status = err.Error()
+		return
+	}
+	defer fstore.Unlock()
+	m.reload()
+	change()
+}
+
+// toggleToday records the selected habit today
+// or, if it's already done today, removes the day.
+func (m *tuiModel) toggleToday() {
+	h, ok := m.selected()
+	if !ok {
+		return
+	}
+	if !h.DoneOn(Now()) {
+		msg, err := m.store.Log(h.Name)
+		if err != nil {
+			m.status = err.Error()
+			return
+		}
+		m.save(msg)
+		return
+	}
+	fstore, ok := m.store.(*FileStore)
+	if !ok {
+		m.status = "Days can be removed only from a local file store."
+		return
+	}
+	if err := fstore.Unlog(h.Name, Now()); err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.save(fmt.Sprintf("Removed today from '%s'.", h.Name))
+}
+
+// backfill records the selected habit on the day selected in the calendar.
+func (m *tuiModel) backfill() {
+	h, ok := m.selected()
+	if !ok {
+		return
+	}
+	if m.day.Equal(RoundDateToDay(Now())) {
+		if !h.DoneOn(m.day) {
+			m.toggleToday()
+		}
+		return
+	}
+	fstore, ok := m.store.(*FileStore)
+	if !ok {
+		m.status = "Past days can be recorded only in a local file store."
+		return
+	}
+	msg, err := fstore.LogOn(h.Name, m.day)
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.save(msg)
+}
+
+// save persists the store and shows the first line of msg.
+func (m *tuiModel) save(msg string) {
+	if err := m.store.Save(); err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.status, _, _ = strings.Cut(msg, "\n")
+	m.reload()
+}
+
+// completionOn returns the habit's completion on the day.
+func completionOn(h Habit, day time.Time) Completion {
+	for _, c := range h.Completions() {
+		if RoundDateToDay(c.Date).Equal(day) {
+			return c
+		}
+	}
+	return Completion{}
+}
+
+// list renders habits with their streaks, marking the selected one.
+func (m *tuiModel) list() string {
+	if len(m.habits) == 0 {
+		return message(msgNotTracking, 0, MessageData{})
+	}
+	width := 0
+	for _, h := range m.habits {
+		width = max(width, utf8.RuneCountInString(h.Name))
+	}
+	today := RoundDateToDay(Now())
+	var sb strings.Builder
+	for i, h := range m.habits {
+		cursor, done := " ", " "
+		if i == m.cursor {
+			cursor = ">"
+		}
+		if h.DoneOn(today) {
+			done = "✔"
+		}
+		streak := "no streak"
+		if s := h.Stats().CurrentStreak; s > 0 {
+			streak = fmt.Sprintf("%d-day streak", s)
+		}
+		fmt.Fprintf(&sb, "%s %s %-*s  %s\n", cursor, done, width, h.Name, streak)
+	}
+	return sb.String()
+}
+
+// view renders the whole screen: the list of habits, the
+// calendar of the selected one and the selected day.
+func (m *tuiModel) view() string {
+	var sb strings.Builder
+	sb.WriteString("habit  ↑/↓ select  space toggle today  ←/→ day  b record day  n note  q quit\n\n")
+	sb.WriteString(m.list())
+	if h, ok := m.selected(); ok {
+		weeks := 12
+		if m.width > 0 {
+			weeks = min(26, max(4, (m.width-6)/2))
+		}
+		fmt.Fprintf(&sb, "\n%s\n", h.Name)
+		sb.WriteString(calendar([]Habit{h}, weeks, m.color, m.day))
+		day := m.day.Format("2006-01-02 Mon")
+		switch c := completionOn(h, m.day); {
+		case m.editing:
+			fmt.Fprintf(&sb, "\nNote for %s: %s▏  (enter save, esc cancel)\n", day, string(m.input))
+		case c.Date.IsZero():
+			fmt.Fprintf(&sb, "\n%s: not done\n", day)
+		case c.Note != "":
+			fmt.Fprintf(&sb, "\n%s: done, %s\n", day, c.Note)
+		default:
+			fmt.Fprintf(&sb, "\n%s: done\n", day)
+		}
+	}
+	if m.status != "" {
+		fmt.Fprintf(&sb, "\n%s\n", m.status)
+	}
+	return sb.String()
+}
+
+// TUI runs the interactive terminal UI on the store: it renders
+// screens of the given width to out and handles keys read from in,
+// until the user quits or in is closed. A width of 0 means unknown.
+//
+// TUI does not switch the terminal to raw mode, so keys
+// are handled only once in delivers them.
+func TUI(s Store, in io.Reader, out io.Writer, width int) error {
+	m := newTUIModel(s, width, isTerminal(out) && os.Getenv("NO_COLOR") == "")
+	buf := make([]byte, 64)
+	for {
+		fmt.Fprint(out, "\x1b[H\x1b[2J"+strings.ReplaceAll(m.view(), "\n", "\r\n"))
+		n, err := in.Read(buf)
+		for _, k := range parseKeys(buf[:n]) {
+			if !m.handle(k) {
+				return nil
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func runTUI(s Store, args []string, wr, ew io.Writer) int {
+	if len(args) != 0 {
+		fmt.Fprint(ew, errors.New("usage: habit tui"))
+		return 1
+	}
+	out, ok := wr.(*os.File)
+	if !ok || !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(out.Fd())) {
+		fmt.Fprintln(ew, "habit tui needs an interactive terminal, listing habits instead.")
+		m := newTUIModel(s, 0, false)
+		m.cursor = len(m.habits) // no habit is selected
+		fmt.Fprint(wr, m.list())
+		return 0
+	}
+	width, _, err := term.GetSize(int(out.Fd()))
+	if err != nil {
+		width = 0
+	}
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	// Switch to the alternate screen and hide the cursor,
+	// restoring both and the terminal mode on exit.
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	err = TUI(s, os.Stdin, out, width)
+	fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+	term.Restore(int(os.Stdin.Fd()), state)
+	if err != nil {
+		fmt.Fprint(ew, err)
+		return 1
+	}
+	return 0
+}
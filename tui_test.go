@@ -0,0 +1,128 @@
+package habit_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/habit"
+)
+
+// runTUI runs the terminal UI on the store with the given
+// keys and returns the last screen rendered.
+func runTUI(t *testing.T, s habit.Store, keys string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := habit.TUI(s, strings.NewReader(keys), &out, 80); err != nil {
+		t.Fatal(err)
+	}
+	screens := strings.Split(out.String(), "\x1b[H\x1b[2J")
+	return strings.ReplaceAll(screens[len(screens)-1], "\r\n", "\n")
+}
+
+func tuiStore(t *testing.T) *habit.FileStore {
+	t.Helper()
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Add(habit.Habit{Name: "jog", Date: day(11).Date, Streak: 2, History: days(10, 11)})
+	store.Add(habit.Habit{Name: "read", Date: day(12).Date, Streak: 2, History: days(11, 12)})
+	return store
+}
+
+func TestTUI_ListsHabitsWithStreaksAndSelectedDay(t *testing.T) {
+	setNow(t, day(12).Date)
+
+	got := runTUI(t, tuiStore(t), "")
+	for _, want := range []string{">   jog   2-day streak\n", "  ✔ read  2-day streak\n", "2022-10-12 Wed: not done\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want screen containing %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestTUI_HighlightsSelectedDayInCalendar(t *testing.T) {
+	setNow(t, day(12).Date)
+
+	rows := func(screen string) []string {
+		var rx []string
+		for _, line := range strings.Split(screen, "\n") {
+			if strings.Contains(line, "[") {
+				rx = append(rx, line)
+			}
+		}
+		return rx
+	}
+	got := rows(runTUI(t, tuiStore(t), "h"))
+	if len(got) != 1 || !strings.HasSuffix(got[0], " ·[█]") {
+		t.Errorf("want yesterday done in brackets, got %q", got)
+	}
+	got = rows(runTUI(t, tuiStore(t), "hhhhhhh"))
+	if len(got) != 1 || !strings.HasPrefix(got[0], "Wed ") || !strings.HasSuffix(got[0], " ·[·]·") {
+		t.Errorf("want Wednesday a week ago in brackets, got %q", got)
+	}
+}
+
+func TestTUI_TogglesTodayOfSelectedHabit(t *testing.T) {
+	setNow(t, day(12).Date)
+
+	store := tuiStore(t)
+	got := runTUI(t, store, " ")
+	if !strings.Contains(got, "Nice work: you've done the habit 'jog' for 3 days in a row now. Keep it up!") {
+		t.Errorf("want message of recorded habit, got:\n%s", got)
+	}
+	saved, err := habit.NewFileStore(store.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, _ := saved.Get("jog"); !h.DoneOn(day(12).Date) {
+		t.Error("want today recorded and saved")
+	}
+
+	runTUI(t, store, "\x1b[B ")
+	if h, _ := store.Get("read"); h.DoneOn(day(12).Date) {
+		t.Error("want today removed from the second habit")
+	}
+}
+
+func TestTUI_BackfillsDayAndAddsNote(t *testing.T) {
+	setNow(t, day(12).Date)
+
+	store := tuiStore(t)
+	got := runTUI(t, store, "hhhb"+"nin the park\x7f\x7f\x7f\x7frain\r")
+	if !strings.Contains(got, "2022-10-09 Sun: done, in the rain\n") {
+		t.Errorf("want selected day done with note, got:\n%s", got)
+	}
+	h, _ := store.Get("jog")
+	want := []time.Time{day(9).Date, day(10).Date, day(11).Date}
+	if !cmp.Equal(want, h.Days()) {
+		t.Error(cmp.Diff(want, h.Days()))
+	}
+}
+
+func TestTUI_QuitsOnQ(t *testing.T) {
+	setNow(t, day(12).Date)
+
+	store := tuiStore(t)
+	runTUI(t, store, "q ")
+	if h, _ := store.Get("jog"); h.DoneOn(day(12).Date) {
+		t.Error("want keys after quitting ignored")
+	}
+}
+
+func TestTUI_SkipsEscapeSequencesWithoutQuitting(t *testing.T) {
+	setNow(t, day(12).Date)
+
+	store := tuiStore(t)
+	// Escape, Alt-q, Delete and Ctrl-Down, then space.
+	runTUI(t, store, "\x1b"+"\x1bq"+"\x1b[3~"+"\x1b[1;5B"+" ")
+	if h, _ := store.Get("jog"); h.DoneOn(day(12).Date) {
+		t.Error("want only the second habit recorded")
+	}
+	if h, _ := store.Get("read"); h.DoneOn(day(12).Date) {
+		t.Error("want today removed from the second habit")
+	}
+}
@@ -0,0 +1,110 @@
+"use strict";
+
+const weeks = 26;
+const day = 24 * 60 * 60 * 1000;
+
+// today returns the current day in UTC, as days are stored by habit.
+function today() {
+  const now = new Date();
+  return Date.UTC(now.getUTCFullYear(), now.getUTCMonth(), now.getUTCDate());
+}
+
+function dayOf(date) {
+  const d = new Date(date);
+  return Date.UTC(d.getUTCFullYear(), d.getUTCMonth(), d.getUTCDate());
+}
+
+// completedDays returns days when the habit was done. Habits stored
+// before the history was recorded report days of their current streak.
+function completedDays(habit) {
+  if (habit.history && habit.history.length > 0) {
+    return new Set(habit.history.map((c) => dayOf(c.date)));
+  }
+  const last = dayOf(habit.date);
+  const days = new Set();
+  for (let i = 0; i < habit.streak; i++) {
+    days.add(last - i * day);
+  }
+  return days;
+}
+
+function streakText(habit) {
+  const since = Math.round((today() - dayOf(habit.date)) / day);
+  if (since > 1) {
+    return [`${since} days since last time`, true];
+  }
+  const unit = habit.streak === 1 ? "day" : "days";
+  return [`🔥 ${habit.streak} ${unit} streak`, false];
+}
+
+function renderHeatmap(el, days) {
+  const end = today();
+  const start = end - (new Date(end).getUTCDay() + 7 * (weeks - 1)) * day;
+  el.replaceChildren();
+  let done = 0;
+  for (let d = start; d < start + weeks * 7 * day; d += day) {
+    const cell = document.createElement("span");
+    cell.title = new Date(d).toISOString().slice(0, 10);
+    if (d > end) {
+      cell.className = "future";
+    } else if (days.has(d)) {
+      cell.className = "done";
+      done++;
+    }
+    el.appendChild(cell);
+  }
+  el.setAttribute("aria-label", `Done ${done} times in the last ${weeks} weeks`);
+}
+
+async function logHabit(name) {
+  const resp = await fetch(`habits/${encodeURIComponent(name)}/log`, { method: "POST" });
+  if (!resp.ok) {
+    const body = await resp.json();
+    alert(body.error);
+  }
+  await refresh();
+}
+
+function render(habits) {
+  const main = document.getElementById("habits");
+  const tmpl = document.getElementById("habit");
+  main.replaceChildren();
+  if (habits.length === 0) {
+    const p = document.createElement("p");
+    p.className = "empty";
+    p.textContent = "You are not tracking any habit yet.";
+    main.appendChild(p);
+    return;
+  }
+  for (const habit of habits) {
+    const node = tmpl.content.cloneNode(true);
+    const days = completedDays(habit);
+    const [text, broken] = streakText(habit);
+    node.querySelector(".name").textContent = habit.name;
+    const streak = node.querySelector(".streak");
+    streak.textContent = text;
+    streak.classList.toggle("broken", broken);
+    const button = node.querySelector(".done");
+    if (days.has(today())) {
+      button.disabled = true;
+      button.textContent = "Done ✔";
+    } else {
+      button.addEventListener("click", () => logHabit(habit.name));
+    }
+    renderHeatmap(node.querySelector(".heatmap"), days);
+    main.appendChild(node);
+  }
+}
+
+async function refresh() {
+  try {
+    const resp = await fetch("habits");
+    render(await resp.json());
+    document.getElementById("updated").textContent = `Updated ${new Date().toLocaleTimeString()}`;
+  } catch (err) {
+    document.getElementById("updated").textContent = `Can't reach habit server: ${err.message}`;
+  }
+}
+
+refresh();
+setInterval(refresh, 60 * 1000);
@@ -0,0 +1,343 @@
+package habit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Names of events sent to webhooks.
+//
+// Events are sent when habits are recorded, as habit runs only when
+// invoked: streak.broken is sent when the habit is recorded again
+// after the streak was broken, not on the day it breaks.
+// Recording a day already recorded sends no events.
+const (
+	WebhookHabitCreated     = "habit.created"
+	WebhookCompletionLogged = "completion.logged"
+	WebhookStreakBroken     = "streak.broken"
+	WebhookMilestoneReached = "milestone.reached"
+)
+
+// webhookEvents holds names of all events sent to webhooks.
+var webhookEvents = []string{WebhookHabitCreated, WebhookCompletionLogged, WebhookStreakBroken, WebhookMilestoneReached}
+
+// WebhookEvent is the JSON payload posted to webhooks.
+//
+// Text holds the message printed by habit, so the payload
+// can be posted as is to Slack-compatible incoming webhooks.
+type WebhookEvent struct {
+	Event        string    `json:"event"`
+	Habit        string    `json:"habit"`
+	Date         string    `json:"date"` // Date holds the day the habit was done on.
+	Streak       int       `json:"streak"`
+	BrokenStreak int       `json:"broken_streak,omitempty"` // BrokenStreak holds length of the streak broken.
+	Time         time.Time `json:"time"`
+	Text         string    `json:"text"`
+}
+
+// createdEvents returns events of starting tracking the habit at the given time.
+func createdEvents(h Habit, t time.Time, msg string) []WebhookEvent {
+	e := WebhookEvent{
+		Event:  WebhookHabitCreated,
+		Habit:  h.Name,
+		Date:   RoundDateToDay(t).Format(dateLayout),
+		Streak: h.Streak,
+		Time:   t,
+		Text:   strings.TrimSpace(msg),
+	}
+	return []WebhookEvent{e}
+}
+
+// recordedEvents returns events of recording habit activity at the
+// given time: a logged completion and, if the activity broke the
+// streak of the given length or reached a milestone, an event for it.
+// It returns no events if the day was already recorded.
+func recordedEvents(e Event, streak int, t time.Time) []WebhookEvent {
+	if e.Kind == EventNone {
+		return nil
+	}
+	logged := WebhookEvent{
+		Event:  WebhookCompletionLogged,
+		Habit:  e.Habit,
+		Date:   RoundDateToDay(t).Format(dateLayout),
+		Streak: e.Streak,
+		Time:   t,
+		Text:   strings.TrimSpace(e.Message),
+	}
+	events := []WebhookEvent{logged}
+	switch e.Kind {
+	case EventRestarted:
+		broken := logged
+		broken.Event, broken.BrokenStreak = WebhookStreakBroken, streak
+		events = append(events, broken)
+	case EventMilestone:
+		reached := logged
+		reached.Event = WebhookMilestoneReached
+		events = append(events, reached)
+	}
+	return events
+}
+
+func (f *FileStore) queueEvents(events []WebhookEvent) {
+	if f.Webhooks == nil || len(events) == 0 {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, events...)
+}
+
+// Webhook represents an HTTP endpoint notified of habit events.
+type Webhook struct {
+	URL string `json:"url"`
+	// Secret, if set, is the key of the HMAC-SHA256 signature
+	// of the payload sent in the X-Habit-Signature header.
+	Secret string `json:"secret,omitempty"`
+	// Events holds names of events sent to the hook, all if empty.
+	Events []string `json:"events,omitempty"`
+}
+
+func (w Webhook) validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL: %q", w.URL)
+	}
+	for _, e := range w.Events {
+		if !slices.Contains(webhookEvents, e) {
+			return fmt.Errorf("webhook %s: unsupported event: %s, want one of: %s", w.URL, e, strings.Join(webhookEvents, ", "))
+		}
+	}
+	return nil
+}
+
+func (w Webhook) wants(event string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, event)
+}
+
+// Sign returns the signature of the payload sent in the
+// X-Habit-Signature header: sha256= followed by the hex
+// encoded HMAC-SHA256 of the payload keyed with the secret.
+func Sign(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookQueueLen holds the number of saves whose events wait
+// for delivery; events of further saves are dropped.
+const webhookQueueLen = 64
+
+// Webhooks delivers habit events to webhooks.
+//
+// Events of saved stores are delivered in the background, in order,
+// so neither saving nor locks held meanwhile wait for slow hooks.
+// Call Wait before exiting to let queued deliveries finish.
+type Webhooks struct {
+	Hooks      []Webhook
+	HTTPClient *http.Client
+	Retries    int           // Retries holds number of retries of failed deliveries.
+	Backoff    time.Duration // Backoff holds delay before the first retry, doubled on every retry.
+	Timeout    time.Duration // Timeout bounds delivery of events saved together, retries included.
+	ErrorLog   io.Writer     // ErrorLog receives errors of failed deliveries, discarded if nil.
+
+	start   sync.Once
+	queue   chan []WebhookEvent
+	pending sync.WaitGroup
+}
+
+// NewWebhooks returns webhooks delivering events to the given hooks.
+func NewWebhooks(hooks []Webhook) *Webhooks {
+	return &Webhooks{
+		Hooks:      hooks,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+		Retries:    3,
+		Backoff:    500 * time.Millisecond,
+		Timeout:    5 * time.Second,
+	}
+}
+
+// LoadWebhooks reads hooks from the JSON file at path and returns
+// webhooks delivering events to them. It returns nil if the file
+// doesn't exist or holds no hooks.
+func LoadWebhooks(path string) (*Webhooks, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var hooks []Webhook
+	if err := dec.Decode(&hooks); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var errs []error
+	for _, w := range hooks {
+		if err := w.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("loading webhooks from %s: %w", path, errors.Join(errs...))
+	}
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+	return NewWebhooks(hooks), nil
+}
+
+// webhooksPath returns path to the file with the user's webhooks.
+//
+// It's habit/webhooks.json in the user's config
+// directory, $XDG_CONFIG_HOME or $HOME/.config on Unix.
+func webhooksPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "habit", "webhooks.json")
+}
+
+// Deliver posts the events to hooks wanting them, each hook
+// concurrently but in order of the events, and waits for them
+// to be delivered or for the timeout. It returns errors of
+// deliveries failed after all retries.
+func (w *Webhooks) Deliver(events []WebhookEvent) error {
+	ctx := context.Background()
+	if w.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.Timeout)
+		defer cancel()
+	}
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, hook := range w.Hooks {
+		wg.Add(1)
+		go func(hook Webhook) {
+			defer wg.Done()
+			for _, e := range events {
+				if !hook.wants(e.Event) {
+					continue
+				}
+				if err := w.post(ctx, hook, e); err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("delivering %s of '%s' to %s: %w", e.Event, e.Habit, hook.URL, err))
+					mu.Unlock()
+				}
+			}
+		}(hook)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// notify queues the events for delivery in the background. If the
+// queue is full, it drops them and reports it to the error log.
+func (w *Webhooks) notify(events []WebhookEvent) {
+	if w == nil || len(events) == 0 {
+		return
+	}
+	w.start.Do(func() {
+		w.queue = make(chan []WebhookEvent, webhookQueueLen)
+		go w.deliverQueued()
+	})
+	w.pending.Add(1)
+	select {
+	case w.queue <- events:
+	default:
+		w.pending.Done()
+		w.logError(fmt.Errorf("too many webhook deliveries waiting, dropped %d events", len(events)))
+	}
+}
+
+// deliverQueued delivers queued events, reporting failures to the error log.
+func (w *Webhooks) deliverQueued() {
+	for events := range w.queue {
+		if err := w.Deliver(events); err != nil {
+			w.logError(err)
+		}
+		w.pending.Done()
+	}
+}
+
+func (w *Webhooks) logError(err error) {
+	if w.ErrorLog != nil {
+		fmt.Fprintln(w.ErrorLog, err)
+	}
+}
+
+// Wait waits for events queued so far to be delivered or to time out.
+func (w *Webhooks) Wait() {
+	if w == nil {
+		return
+	}
+	w.pending.Wait()
+}
+
+// post sends the event to the hook. It retries deliveries failed
+// because of network or server errors, or rate limiting, waiting
+// longer before every retry, until the context is done.
+func (w *Webhooks) post(ctx context.Context, hook Webhook, e WebhookEvent) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	backoff := w.Backoff
+	var lastErr error
+	for attempt := 0; attempt <= w.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return fmt.Errorf("%w, last error: %v", ctx.Err(), lastErr)
+			}
+			backoff *= 2
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Habit-Event", e.Event)
+		if hook.Secret != "" {
+			req.Header.Set("X-Habit-Signature", Sign(payload, hook.Secret))
+		}
+		resp, err := w.HTTPClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			lastErr = errors.New(resp.Status)
+			continue
+		}
+		if resp.StatusCode >= 400 {
+			return errors.New(resp.Status)
+		}
+		return nil
+	}
+	return lastErr
+}
@@ -0,0 +1,228 @@
+package habit_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/habit"
+)
+
+// webhookRecorder records events posted to it,
+// failing the first failures requests with status.
+type webhookRecorder struct {
+	mu       sync.Mutex
+	events   []habit.WebhookEvent
+	requests int
+	failures int
+	status   int
+	secret   string
+	t        *testing.T
+}
+
+func (rec *webhookRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.requests++
+	if rec.requests <= rec.failures {
+		w.WriteHeader(rec.status)
+		return
+	}
+	payload, _ := io.ReadAll(r.Body)
+	want := ""
+	if rec.secret != "" {
+		want = habit.Sign(payload, rec.secret)
+	}
+	if got := r.Header.Get("X-Habit-Signature"); want != got {
+		rec.t.Errorf("want signature %q, got %q", want, got)
+	}
+	var e habit.WebhookEvent
+	if err := json.Unmarshal(payload, &e); err != nil {
+		rec.t.Error(err)
+	}
+	if got := r.Header.Get("X-Habit-Event"); got != e.Event {
+		rec.t.Errorf("want X-Habit-Event header %q, got %q", e.Event, got)
+	}
+	rec.events = append(rec.events, e)
+}
+
+func webhookStore(t *testing.T, hooks ...habit.Webhook) *habit.FileStore {
+	t.Helper()
+	store, err := habit.NewFileStore(testPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Webhooks = habit.NewWebhooks(hooks)
+	store.Webhooks.Backoff = time.Millisecond
+	return store
+}
+
+func TestSave_DeliversEventsOfLoggedHabits(t *testing.T) {
+	rec := &webhookRecorder{secret: "s3cret", t: t}
+	ts := httptest.NewServer(rec)
+	defer ts.Close()
+	store := webhookStore(t, habit.Webhook{URL: ts.URL, Secret: "s3cret"})
+
+	for _, d := range []int{1, 2, 3, 7} {
+		setNow(t, day(d).Date)
+		if _, err := store.Log("jog"); err != nil {
+			t.Fatal(err)
+		}
+		if d == 1 {
+			h, _ := store.Get("jog")
+			h.Milestones = []int{3}
+			store.Add(h)
+		}
+	}
+	if len(rec.events) != 0 {
+		t.Fatalf("want no events delivered before saving, got %v", rec.events)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	store.Webhooks.Wait()
+	want := []habit.WebhookEvent{
+		{Event: habit.WebhookHabitCreated, Habit: "jog", Date: "2022-10-01", Streak: 1, Time: day(1).Date},
+		{Event: habit.WebhookCompletionLogged, Habit: "jog", Date: "2022-10-02", Streak: 2, Time: day(2).Date},
+		{Event: habit.WebhookCompletionLogged, Habit: "jog", Date: "2022-10-03", Streak: 3, Time: day(3).Date},
+		{Event: habit.WebhookMilestoneReached, Habit: "jog", Date: "2022-10-03", Streak: 3, Time: day(3).Date},
+		{Event: habit.WebhookCompletionLogged, Habit: "jog", Date: "2022-10-07", Streak: 1, Time: day(7).Date},
+		{Event: habit.WebhookStreakBroken, Habit: "jog", Date: "2022-10-07", Streak: 1, BrokenStreak: 3, Time: day(7).Date},
+	}
+	ignoreText := cmp.FilterPath(func(p cmp.Path) bool { return p.Last().String() == ".Text" }, cmp.Ignore())
+	if !cmp.Equal(want, rec.events, ignoreText) {
+		t.Error(cmp.Diff(want, rec.events, ignoreText))
+	}
+	if want := "you've done the habit 'jog' for 3 days in a row"; !strings.Contains(rec.events[3].Text, want) {
+		t.Errorf("want text of milestone containing %q, got %q", want, rec.events[3].Text)
+	}
+
+	// Events are delivered once, and recording
+	// a day already recorded sends none.
+	if _, err := store.LogOn("jog", day(2).Date); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	store.Webhooks.Wait()
+	if len(rec.events) != len(want) {
+		t.Errorf("want %d events after saving again, got %d", len(want), len(rec.events))
+	}
+}
+
+func TestSave_DoesNotWaitForSlowWebhooks(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+	store := webhookStore(t, habit.Webhook{URL: ts.URL})
+	store.Webhooks.Timeout = 200 * time.Millisecond
+	var errLog strings.Builder
+	store.Webhooks.ErrorLog = &errLog
+	setNow(t, day(1).Date)
+
+	if _, err := store.Log("jog"); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("want save not waiting for the webhook, took %v", elapsed)
+	}
+	store.Webhooks.Wait()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("want delivery given up after the timeout, took %v", elapsed)
+	}
+	if !strings.Contains(errLog.String(), "context deadline exceeded") {
+		t.Errorf("want timeout reported, got %q", errLog.String())
+	}
+}
+
+func TestDeliver_SendsOnlyEventsHookWants(t *testing.T) {
+	rec := &webhookRecorder{t: t}
+	ts := httptest.NewServer(rec)
+	defer ts.Close()
+	w := habit.NewWebhooks([]habit.Webhook{{URL: ts.URL, Events: []string{habit.WebhookStreakBroken}}})
+
+	err := w.Deliver([]habit.WebhookEvent{
+		{Event: habit.WebhookCompletionLogged, Habit: "jog"},
+		{Event: habit.WebhookStreakBroken, Habit: "jog"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.events) != 1 || rec.events[0].Event != habit.WebhookStreakBroken {
+		t.Errorf("want only streak broken event, got %v", rec.events)
+	}
+}
+
+func TestDeliver_RetriesServerErrors(t *testing.T) {
+	rec := &webhookRecorder{t: t, failures: 2, status: http.StatusBadGateway}
+	ts := httptest.NewServer(rec)
+	defer ts.Close()
+	w := habit.NewWebhooks([]habit.Webhook{{URL: ts.URL}})
+	w.Backoff = time.Millisecond
+
+	if err := w.Deliver([]habit.WebhookEvent{{Event: habit.WebhookHabitCreated, Habit: "jog"}}); err != nil {
+		t.Fatal(err)
+	}
+	if rec.requests != 3 || len(rec.events) != 1 {
+		t.Errorf("want event delivered on the third request, got %d requests, %d events", rec.requests, len(rec.events))
+	}
+
+	w.Retries = 1
+	rec.requests, rec.failures = 0, 5
+	if err := w.Deliver([]habit.WebhookEvent{{Event: habit.WebhookHabitCreated, Habit: "jog"}}); err == nil {
+		t.Error("want error after all retries failed")
+	}
+	if rec.requests != 2 {
+		t.Errorf("want 2 requests, got %d", rec.requests)
+	}
+}
+
+func TestDeliver_DoesNotRetryClientErrors(t *testing.T) {
+	rec := &webhookRecorder{t: t, failures: 5, status: http.StatusNotFound}
+	ts := httptest.NewServer(rec)
+	defer ts.Close()
+	w := habit.NewWebhooks([]habit.Webhook{{URL: ts.URL}})
+	w.Backoff = time.Millisecond
+
+	err := w.Deliver([]habit.WebhookEvent{{Event: habit.WebhookHabitCreated, Habit: "jog"}})
+	if err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Errorf("want not found error, got %v", err)
+	}
+	if rec.requests != 1 {
+		t.Errorf("want 1 request, got %d", rec.requests)
+	}
+}
+
+func TestLoadWebhooks_ErrorsOnInvalidHooks(t *testing.T) {
+	path := t.TempDir() + "/webhooks.json"
+	if w, err := habit.LoadWebhooks(path); w != nil || err != nil {
+		t.Errorf("want no webhooks without file, got %v, %v", w, err)
+	}
+	for _, data := range []string{
+		`[{"url": "ftp://example.com"}]`,
+		`[{"url": "https://example.com", "events": ["habit.deleted"]}]`,
+		`[{"url": "https://example.com", "method": "PUT"}]`,
+	} {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := habit.LoadWebhooks(path); err == nil {
+			t.Errorf("want error loading %s", data)
+		}
+	}
+}